@@ -9,11 +9,67 @@ import (
 )
 
 // PacketCodec 报文编解码器
-type PacketCodec struct{}
+type PacketCodec struct {
+	forcedCS     *byte // 编码时强制使用的CS(仅用于测试/互通调试)
+	skipCSVerify bool  // 解码时不校验CS
+	startFlag    byte  // 帧起始标识
+	endFlag      byte  // 帧结束标识
+}
+
+// Option 编解码器选项
+type Option func(*PacketCodec)
+
+// WithForcedCS 编码时不计算CS,而是写入指定的值
+// 仅用于测试和与第三方设备的互通调试(如复现厂商的非标准校验),
+// 不影响解码时的CS校验
+func WithForcedCS(cs byte) Option {
+	return func(c *PacketCodec) {
+		c.forcedCS = &cs
+	}
+}
+
+// SkipCSVerify 解码时不因CS不符而拒绝帧,而是将帧标记为Unverified
+// 用于从校验实现有缺陷的链路中恢复数据,默认严格校验
+func SkipCSVerify() Option {
+	return func(c *PacketCodec) {
+		c.skipCSVerify = true
+	}
+}
+
+// WithStartFlag 设置帧起始标识,用于起始符不同的衍生协议,默认为68H
+func WithStartFlag(b byte) Option {
+	return func(c *PacketCodec) {
+		c.startFlag = b
+	}
+}
+
+// WithEndFlag 设置帧结束标识,用于结束符不同的衍生协议,默认为16H
+func WithEndFlag(b byte) Option {
+	return func(c *PacketCodec) {
+		c.endFlag = b
+	}
+}
 
 // NewPacketCodec 创建新的编解码器实例
-func NewPacketCodec() *PacketCodec {
-	return &PacketCodec{}
+func NewPacketCodec(opts ...Option) *PacketCodec {
+	c := &PacketCodec{
+		startFlag: types.StartFlag,
+		endFlag:   types.EndFlag,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// StartFlag 返回帧起始标识
+func (c *PacketCodec) StartFlag() byte {
+	return c.startFlag
+}
+
+// EndFlag 返回帧结束标识
+func (c *PacketCodec) EndFlag() byte {
+	return c.endFlag
 }
 
 // DecodePacket 将字节流解码为Frame
@@ -24,10 +80,10 @@ func (c *PacketCodec) DecodePacket(data []byte) (*types.Frame, error) {
 	}
 
 	// 2. 检查起始和结束标识
-	if data[0] != types.StartFlag || data[2] != types.StartFlag {
+	if data[0] != c.startFlag || data[2] != c.startFlag {
 		return nil, fmt.Errorf("invalid start flag")
 	}
-	if data[len(data)-1] != types.EndFlag {
+	if data[len(data)-1] != c.endFlag {
 		return nil, fmt.Errorf("invalid end flag")
 	}
 
@@ -46,7 +102,8 @@ func (c *PacketCodec) DecodePacket(data []byte) (*types.Frame, error) {
 	// 5. 校验CS
 	expectedCS := c.calculateCS(userData)
 	actualCS := data[len(data)-2]
-	if expectedCS != actualCS {
+	unverified := expectedCS != actualCS
+	if unverified && !c.skipCSVerify {
 		return nil, fmt.Errorf("CS 校验失败，期望 %X, 实际 %X", expectedCS, actualCS)
 	}
 
@@ -60,51 +117,98 @@ func (c *PacketCodec) DecodePacket(data []byte) (*types.Frame, error) {
 		UserDataRaw: userData,
 		CS:          actualCS,
 		EndFlag:     data[len(data)-1],
+		Unverified:  unverified,
 	}
 
 	return frame, nil
 }
 
+// DecodeFrames 解码包含一个或多个首尾相接帧的数据(如单个UDP数据报)
+// 按各帧的长度字段L依次切分,每帧长度为L+5。
+// 末尾不足一帧或某帧解码失败时返回错误,同时返回此前已成功解码的帧。
+func (c *PacketCodec) DecodeFrames(data []byte) ([]*types.Frame, error) {
+	var frames []*types.Frame
+	offset := 0
+	for offset < len(data) {
+		rest := data[offset:]
+		if len(rest) < 3 {
+			return frames, fmt.Errorf("偏移%d: 帧头不完整: %d字节", offset, len(rest))
+		}
+		if rest[0] != c.startFlag {
+			return frames, fmt.Errorf("偏移%d: 起始标识错误: 0x%02X", offset, rest[0])
+		}
+
+		frameLen := int(rest[1]) + 5
+		if len(rest) < frameLen {
+			return frames, fmt.Errorf("偏移%d: 帧不完整: 期望%d字节,实际%d字节", offset, frameLen, len(rest))
+		}
+
+		frame, err := c.DecodePacket(rest[:frameLen])
+		if err != nil {
+			return frames, fmt.Errorf("偏移%d: %w", offset, err)
+		}
+		frames = append(frames, frame)
+		offset += frameLen
+	}
+	return frames, nil
+}
+
 // EncodePacket 将Frame编码为字节流
 func (c *PacketCodec) EncodePacket(frame *types.Frame) ([]byte, error) {
 	// 预分配缓冲区
 	buf := bytes.Buffer{}
 
 	// 1. 写入帧头
-	buf.WriteByte(frame.Head.StartFlag1)
+	buf.WriteByte(c.startFlag)
 	buf.WriteByte(frame.Head.Length)
-	buf.WriteByte(frame.Head.StartFlag2)
+	buf.WriteByte(c.startFlag)
 
 	// 2. 写入用户数据区
 	buf.Write(frame.UserDataRaw)
 
-	// 3. 计算并写入CS
+	// 3. 计算并写入CS(设置了强制CS时直接写入)
 	cs := c.calculateCS(frame.UserDataRaw)
+	if c.forcedCS != nil {
+		cs = *c.forcedCS
+	}
 	buf.WriteByte(cs)
 
 	// 4. 写入帧结束标识
-	buf.WriteByte(types.EndFlag)
+	buf.WriteByte(c.endFlag)
 
 	return buf.Bytes(), nil
 }
 
-// calculateCS 计算用户数据区的CRC校验
-// 生成多项式: X7+X6+X5+X2+1 = 1110 0100
-func (c *PacketCodec) calculateCS(data []byte) byte {
-	var crc byte
-	const poly = 0xE4 // 生成多项式: X7+X6+X5+X2+1 = 1110 0100
+// Checksum 计算用户数据区的CS校验码
+func (c *PacketCodec) Checksum(userData []byte) byte {
+	return c.calculateCS(userData)
+}
 
-	for _, b := range data {
-		crc ^= b // 与输入字节异或
+// csPoly CS校验生成多项式: X7+X6+X5+X2+1 = 1110 0100
+const csPoly = 0xE4
 
-		for i := 0; i < 8; i++ {
+// csTable 按字节预计算的CS校验表
+var csTable = func() (table [256]byte) {
+	for i := range table {
+		crc := byte(i)
+		for j := 0; j < 8; j++ {
 			if (crc & 0x80) != 0 { // 检查最高位是1
-				crc = (crc << 1) ^ poly // 左移并异或多项式
+				crc = (crc << 1) ^ csPoly // 左移并异或多项式
 			} else {
 				crc = crc << 1 // 只左移
 			}
 		}
+		table[i] = crc
 	}
+	return table
+}()
 
+// calculateCS 计算用户数据区的CRC校验
+// 生成多项式: X7+X6+X5+X2+1 = 1110 0100,按字节查表计算
+func (c *PacketCodec) calculateCS(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc = csTable[crc^b]
+	}
 	return crc & 0x7F // 返回低7位作为校验值
 }
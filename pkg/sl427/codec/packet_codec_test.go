@@ -3,6 +3,7 @@ package codec
 import (
 	"testing"
 
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,7 +19,7 @@ func TestPacketCodec_Simple(t *testing.T) {
 	}
 
 	// 计算CS
-	cs := calculateCS(userData)
+	cs := codec.calculateCS(userData)
 
 	// 构造完整帧
 	packet := []byte{
@@ -54,15 +55,6 @@ func TestPacketCodec_Simple(t *testing.T) {
 	}
 }
 
-// 用于测试的CS计算函数，与codec中的实现保持一致
-func calculateCS(data []byte) byte {
-	var cs byte
-	for _, b := range data {
-		cs ^= b
-	}
-	return cs
-}
-
 func TestPacketCodec_DecodeInvalid(t *testing.T) {
 	codec := NewPacketCodec()
 
@@ -91,3 +83,160 @@ func TestPacketCodec_InvalidInput(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// buildPacket 用给定的用户数据区构造完整帧
+func buildPacket(userData []byte) []byte {
+	packet := []byte{0x68, byte(len(userData)), 0x68}
+	packet = append(packet, userData...)
+	packet = append(packet, NewPacketCodec().calculateCS(userData), 0x16)
+	return packet
+}
+
+func TestPacketCodec_DecodeFrames(t *testing.T) {
+	codec := NewPacketCodec()
+	first := buildPacket([]byte{0x82, 0x12, 0x34, 0x56, 0x00, 0x01, 0xC0, 0x34, 0x12, 0x05, 0x00})
+	second := buildPacket([]byte{0x81, 0x12, 0x34, 0x56, 0x00, 0x02, 0xC0, 0x00, 0x12, 0x34})
+	datagram := append(append([]byte{}, first...), second...)
+
+	t.Run("two frames", func(t *testing.T) {
+		frames, err := codec.DecodeFrames(datagram)
+		assert.NoError(t, err)
+		if assert.Len(t, frames, 2) {
+			assert.Equal(t, first, frames[0].Raw())
+			assert.Equal(t, second, frames[1].Raw())
+		}
+	})
+
+	t.Run("trailing partial frame", func(t *testing.T) {
+		frames, err := codec.DecodeFrames(datagram[:len(datagram)-3])
+		assert.Error(t, err)
+		assert.Len(t, frames, 1)
+	})
+
+	t.Run("garbage after frame", func(t *testing.T) {
+		frames, err := codec.DecodeFrames(append(append([]byte{}, first...), 0x00, 0x01, 0x02))
+		assert.Error(t, err)
+		assert.Len(t, frames, 1)
+	})
+}
+
+// calculateCSBitwise 逐位计算CS校验,作为查表实现的参照
+func calculateCSBitwise(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if (crc & 0x80) != 0 {
+				crc = (crc << 1) ^ csPoly
+			} else {
+				crc = crc << 1
+			}
+		}
+	}
+	return crc & 0x7F
+}
+
+func TestPacketCodec_CSTable(t *testing.T) {
+	codec := NewPacketCodec()
+	for i := 0; i < 256; i++ {
+		data := []byte{byte(i)}
+		assert.Equal(t, calculateCSBitwise(data), codec.calculateCS(data), "byte %02X", i)
+	}
+
+	long := make([]byte, 255)
+	for i := range long {
+		long[i] = byte(i*7 + 3)
+	}
+	assert.Equal(t, calculateCSBitwise(long), codec.calculateCS(long))
+}
+
+func BenchmarkCalculateCS(b *testing.B) {
+	data := make([]byte, 255)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	b.Run("table", func(b *testing.B) {
+		codec := NewPacketCodec()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			codec.calculateCS(data)
+		}
+	})
+
+	b.Run("bitwise", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			calculateCSBitwise(data)
+		}
+	})
+}
+
+func TestPacketCodec_ForcedCS(t *testing.T) {
+	userData := []byte{0x81, 0x12, 0x34, 0x56, 0x00, 0x01, 0xC0, 0x00, 0x12, 0x34}
+	frame := &types.Frame{
+		Head:        types.Header{StartFlag1: types.StartFlag, Length: byte(len(userData)), StartFlag2: types.StartFlag},
+		UserDataRaw: userData,
+		EndFlag:     types.EndFlag,
+	}
+	forced := NewPacketCodec().calculateCS(userData) ^ 0x01
+
+	raw, err := NewPacketCodec(WithForcedCS(forced)).EncodePacket(frame)
+	assert.NoError(t, err)
+	assert.Equal(t, forced, raw[len(raw)-2])
+
+	// 解码仍然正常校验CS
+	_, err = NewPacketCodec(WithForcedCS(forced)).DecodePacket(raw)
+	assert.Error(t, err)
+
+	decoded, err := NewPacketCodec(SkipCSVerify()).DecodePacket(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, forced, decoded.CS)
+}
+
+func TestPacketCodec_SkipCSVerify(t *testing.T) {
+	packet := buildPacket([]byte{0x81, 0x12, 0x34, 0x56, 0x00, 0x01, 0xC0, 0x00, 0x12, 0x34})
+
+	frame, err := NewPacketCodec(SkipCSVerify()).DecodePacket(packet)
+	assert.NoError(t, err)
+	assert.False(t, frame.Unverified)
+
+	bad := append([]byte{}, packet...)
+	bad[len(bad)-2] ^= 0x01
+	_, err = NewPacketCodec().DecodePacket(bad)
+	assert.Error(t, err)
+
+	frame, err = NewPacketCodec(SkipCSVerify()).DecodePacket(bad)
+	assert.NoError(t, err)
+	assert.True(t, frame.Unverified)
+	assert.Equal(t, packet[3:len(packet)-2], frame.UserDataRaw)
+	assert.Equal(t, bad[len(bad)-2], frame.CS)
+}
+
+func TestPacketCodec_CustomFlags(t *testing.T) {
+	userData := []byte{0x81, 0x12, 0x34, 0x56, 0x00, 0x01, 0xC0, 0x00, 0x12, 0x34}
+	frame := &types.Frame{
+		Head:        types.Header{StartFlag1: types.StartFlag, Length: byte(len(userData)), StartFlag2: types.StartFlag},
+		UserDataRaw: userData,
+	}
+	custom := NewPacketCodec(WithStartFlag(0x7E), WithEndFlag(0x7F))
+
+	raw, err := custom.EncodePacket(frame)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x7E), raw[0])
+	assert.Equal(t, byte(0x7E), raw[2])
+	assert.Equal(t, byte(0x7F), raw[len(raw)-1])
+
+	decoded, err := custom.DecodePacket(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, userData, decoded.UserDataRaw)
+	assert.Equal(t, byte(0x7F), decoded.EndFlag)
+
+	// 标识不匹配时拒绝
+	_, err = NewPacketCodec().DecodePacket(raw)
+	assert.Error(t, err)
+	_, err = custom.DecodePacket(buildPacket(userData))
+	assert.Error(t, err)
+	_, err = NewPacketCodec(WithStartFlag(0x7E)).DecodePacket(raw)
+	assert.Error(t, err)
+}
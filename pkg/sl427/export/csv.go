@@ -0,0 +1,79 @@
+// pkg/sl427/export/csv.go
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/packet"
+)
+
+// TimeLayout CSV中时间列的格式
+const TimeLayout = "2006-01-02 15:04:05"
+
+// WriteCSV 将一批自报数据写为CSV
+// 表头为timestamp、address以及所有数据出现过的数据项key(按字典序),
+// 每条自报数据一行,该条数据没有的数据项留空
+func WriteCSV(w io.Writer, rows []packet.AddressedUpload) error {
+	items := make([]map[string]interface{}, len(rows))
+	columns := make(map[string]bool)
+	for i, row := range rows {
+		if err := json.Unmarshal(row.Items, &items[i]); err != nil {
+			return fmt.Errorf("解析第%d条数据项失败: %v", i+1, err)
+		}
+		for k := range items[i] {
+			columns[k] = true
+		}
+	}
+
+	keys := make([]string, 0, len(columns))
+	for k := range columns {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"timestamp", "address"}, keys...)); err != nil {
+		return err
+	}
+
+	record := make([]string, 2+len(keys))
+	for i, row := range rows {
+		record[0] = ""
+		if row.Timestamp != nil {
+			record[0] = time.Unix(row.Timestamp.Seconds(), 0).Format(TimeLayout)
+		}
+		record[1] = ""
+		if row.Address != nil {
+			record[1] = row.Address.GetAddress()
+		}
+		for j, k := range keys {
+			record[2+j] = formatValue(items[i][k])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatValue 将数据项的值格式化为CSV单元格,不存在的数据项为空
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
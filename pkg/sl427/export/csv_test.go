@@ -0,0 +1,35 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/packet"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	collected := time.Date(2024, 11, 10, 8, 0, 0, 0, time.Local)
+	addr, err := types.NewAddressV2([]byte{0x80, 0x00, 0x00, 0x01})
+	require.NoError(t, err)
+
+	level, err := types.ParseUploadData(types.DataTypeWaterLevel, []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	level.Timestamp = types.NewTimestamp(collected)
+	rain, err := types.ParseUploadData(types.DataTypeRain, []byte{0x00, 0x12, 0x34, 0x00, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = WriteCSV(&buf, []packet.AddressedUpload{
+		{Address: addr, UploadFrame: level},
+		{Address: addr, UploadFrame: rain},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "timestamp,address,SW,SW2,YL\n"+
+		"2024-11-10 08:00:00,80000001,51.234,0.1,\n"+
+		",80000001,,,123.4\n", buf.String())
+}
@@ -2,6 +2,7 @@
 package metrics
 
 import (
+	"math"
 	"sync/atomic"
 	"time"
 )
@@ -11,9 +12,69 @@ type Metrics struct {
 	PacketsReceived   uint64        // 接收的数据包数量
 	PacketsSent       uint64        // 发送的数据包数量
 	PacketsDropped    uint64        // 丢弃的数据包数量
+	PacketsTruncated  uint64        // 连接在帧中途关闭导致不完整的帧数量
+	BytesReceived     uint64        // 接收的字节数
+	BytesSent         uint64        // 发送的字节数
 	LastReceiveTime   atomic.Value  // 最后接收时间
 	LastTransmitTime  atomic.Value  // 最后发送时间
-	ProcessingLatency time.Duration // 处理延迟
+	ProcessingLatency time.Duration // 处理延迟(最近一次)
+
+	latencyCounts [len(LatencyBuckets) + 1]uint64 // 各延迟区间计数,最后一个为溢出区间
+	latencySum    int64                           // 延迟总和(纳秒)
+}
+
+// LatencyBuckets 延迟直方图各区间的上界(含),超过最后一个上界的计入溢出区间
+var LatencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyHistogram 处理延迟直方图
+// Count由Counts求和得到,两者总是一致;Sum单独累计,
+// 与并发的RecordLatency交错时可能多含或少含个别延迟,Mean只是近似值
+type LatencyHistogram struct {
+	Bounds []time.Duration // 各区间上界,与LatencyBuckets一致
+	Counts []uint64        // 各区间计数(非累计),比Bounds多一个溢出区间
+	Count  uint64          // 记录总数
+	Sum    time.Duration   // 延迟总和
+}
+
+// Mean 返回平均延迟
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile 估算分位数(如0.5、0.95),返回该分位所在区间的上界
+// 落在溢出区间时返回最大的有限上界
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 || len(h.Bounds) == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var cumulative uint64
+	for i, c := range h.Counts {
+		cumulative += c
+		if cumulative >= rank && i < len(h.Bounds) {
+			return h.Bounds[i]
+		}
+	}
+	return h.Bounds[len(h.Bounds)-1]
 }
 
 // NewMetrics 创建新的监控指标实例
@@ -36,12 +97,106 @@ func (m *Metrics) RecordSend() {
 	m.LastTransmitTime.Store(time.Now())
 }
 
+// RecordReceiveBytes 记录接收的字节数
+func (m *Metrics) RecordReceiveBytes(n int) {
+	atomic.AddUint64(&m.BytesReceived, uint64(n))
+}
+
+// RecordSendBytes 记录发送的字节数
+func (m *Metrics) RecordSendBytes(n int) {
+	atomic.AddUint64(&m.BytesSent, uint64(n))
+}
+
 // RecordDrop 记录数据包丢弃
 func (m *Metrics) RecordDrop() {
 	atomic.AddUint64(&m.PacketsDropped, 1)
 }
 
+// RecordTruncated 记录一个因连接中途关闭而不完整的帧
+func (m *Metrics) RecordTruncated() {
+	atomic.AddUint64(&m.PacketsTruncated, 1)
+}
+
 // RecordLatency 记录处理延迟
 func (m *Metrics) RecordLatency(start time.Time) {
-	m.ProcessingLatency = time.Since(start)
+	d := time.Since(start)
+	atomic.StoreInt64((*int64)(&m.ProcessingLatency), int64(d))
+
+	i := 0
+	for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+		i++
+	}
+	atomic.AddUint64(&m.latencyCounts[i], 1)
+	atomic.AddInt64(&m.latencySum, int64(d))
+}
+
+// Latency 返回处理延迟直方图
+func (m *Metrics) Latency() LatencyHistogram {
+	return m.latency(atomic.LoadUint64, atomic.LoadInt64)
+}
+
+// latency 使用给定的原子读取(或交换)函数生成直方图
+func (m *Metrics) latency(loadUint func(*uint64) uint64, loadInt func(*int64) int64) LatencyHistogram {
+	h := LatencyHistogram{
+		Bounds: append([]time.Duration(nil), LatencyBuckets[:]...),
+		Counts: make([]uint64, len(m.latencyCounts)),
+	}
+	for i := range m.latencyCounts {
+		h.Counts[i] = loadUint(&m.latencyCounts[i])
+		h.Count += h.Counts[i]
+	}
+	h.Sum = time.Duration(loadInt(&m.latencySum))
+	return h
+}
+
+// Snapshot 监控指标在某一时刻的快照
+type Snapshot struct {
+	PacketsReceived   uint64           // 接收的数据包数量
+	PacketsSent       uint64           // 发送的数据包数量
+	PacketsDropped    uint64           // 丢弃的数据包数量
+	PacketsTruncated  uint64           // 不完整的帧数量
+	BytesReceived     uint64           // 接收的字节数
+	BytesSent         uint64           // 发送的字节数
+	LastReceiveTime   time.Time        // 最后接收时间
+	LastTransmitTime  time.Time        // 最后发送时间
+	ProcessingLatency time.Duration    // 处理延迟(最近一次)
+	Latency           LatencyHistogram // 处理延迟直方图
+}
+
+// Snapshot 返回当前监控指标的快照
+// 零值Metrics也可以使用,尚未记录过的时间戳为零值时间
+func (m *Metrics) Snapshot() Snapshot {
+	lastReceive, _ := m.LastReceiveTime.Load().(time.Time)
+	lastTransmit, _ := m.LastTransmitTime.Load().(time.Time)
+	return Snapshot{
+		PacketsReceived:   atomic.LoadUint64(&m.PacketsReceived),
+		PacketsSent:       atomic.LoadUint64(&m.PacketsSent),
+		PacketsDropped:    atomic.LoadUint64(&m.PacketsDropped),
+		PacketsTruncated:  atomic.LoadUint64(&m.PacketsTruncated),
+		BytesReceived:     atomic.LoadUint64(&m.BytesReceived),
+		BytesSent:         atomic.LoadUint64(&m.BytesSent),
+		LastReceiveTime:   lastReceive,
+		LastTransmitTime:  lastTransmit,
+		ProcessingLatency: time.Duration(atomic.LoadInt64((*int64)(&m.ProcessingLatency))),
+		Latency:           m.Latency(),
+	}
+}
+
+// Reset 将计数器和延迟直方图清零并返回清零前的快照,时间戳和最近一次处理延迟保持不变
+// 每个计数器通过原子交换清零,期间并发的记录不会丢失:
+// 要么计入返回的快照,要么计入下一个窗口。
+// 周期性调用Reset即可得到每个窗口内的增量,除以窗口时长即为速率。
+func (m *Metrics) Reset() Snapshot {
+	s := m.Snapshot()
+	s.PacketsReceived = atomic.SwapUint64(&m.PacketsReceived, 0)
+	s.PacketsSent = atomic.SwapUint64(&m.PacketsSent, 0)
+	s.PacketsDropped = atomic.SwapUint64(&m.PacketsDropped, 0)
+	s.PacketsTruncated = atomic.SwapUint64(&m.PacketsTruncated, 0)
+	s.BytesReceived = atomic.SwapUint64(&m.BytesReceived, 0)
+	s.BytesSent = atomic.SwapUint64(&m.BytesSent, 0)
+	s.Latency = m.latency(
+		func(p *uint64) uint64 { return atomic.SwapUint64(p, 0) },
+		func(p *int64) int64 { return atomic.SwapInt64(p, 0) },
+	)
+	return s
 }
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Reset(t *testing.T) {
+	m := NewMetrics()
+	m.RecordReceive()
+	m.RecordReceive()
+	m.RecordSend()
+	m.RecordDrop()
+	last := m.Snapshot().LastReceiveTime
+
+	window := m.Reset()
+	assert.Equal(t, uint64(2), window.PacketsReceived)
+	assert.Equal(t, uint64(1), window.PacketsSent)
+	assert.Equal(t, uint64(1), window.PacketsDropped)
+
+	s := m.Snapshot()
+	assert.Zero(t, s.PacketsReceived)
+	assert.Zero(t, s.PacketsSent)
+	assert.Zero(t, s.PacketsDropped)
+	assert.Equal(t, last, s.LastReceiveTime)
+}
+
+func TestMetrics_ResetConcurrent(t *testing.T) {
+	const workers, perWorker = 8, 1000
+	m := NewMetrics()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				m.RecordReceive()
+			}
+		}()
+	}
+
+	// 记录的同时不断重置,所有窗口之和应等于总记录数
+	var total uint64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		total += m.Reset().PacketsReceived
+	}
+
+	assert.Equal(t, uint64(workers*perWorker), total)
+}
+
+func TestMetrics_ZeroValue(t *testing.T) {
+	var m Metrics
+	assert.NotPanics(t, func() {
+		s := m.Snapshot()
+		assert.True(t, s.LastReceiveTime.IsZero())
+		m.RecordDrop()
+		assert.Equal(t, uint64(1), m.Reset().PacketsDropped)
+	})
+}
+
+func TestMetrics_LatencyResetConsistent(t *testing.T) {
+	m := NewMetrics()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.RecordLatency(time.Now())
+			}
+		}()
+	}
+
+	// 并发记录时每个窗口的Count都与各区间计数之和一致
+	var total uint64
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		h := m.Reset().Latency
+		var sum uint64
+		for _, c := range h.Counts {
+			sum += c
+		}
+		assert.Equal(t, sum, h.Count)
+		total += h.Count
+	}
+	assert.Equal(t, uint64(4000), total)
+}
+
+func TestMetrics_Bytes(t *testing.T) {
+	m := NewMetrics()
+	m.RecordReceiveBytes(20)
+	m.RecordReceiveBytes(15)
+	m.RecordSendBytes(12)
+
+	s := m.Snapshot()
+	assert.Equal(t, uint64(35), s.BytesReceived)
+	assert.Equal(t, uint64(12), s.BytesSent)
+
+	window := m.Reset()
+	assert.Equal(t, uint64(35), window.BytesReceived)
+	assert.Zero(t, m.Snapshot().BytesReceived)
+}
+
+func TestMetrics_LatencyHistogram(t *testing.T) {
+	m := NewMetrics()
+	// 延迟取值避开区间边界,记录耗时带来的微小偏差不影响归属
+	latencies := []time.Duration{
+		500 * time.Microsecond, 500 * time.Microsecond, 500 * time.Microsecond,
+		3 * time.Millisecond,
+		30 * time.Millisecond,
+		2 * time.Second,
+	}
+	for _, d := range latencies {
+		m.RecordLatency(time.Now().Add(-d))
+	}
+
+	h := m.Latency()
+	assert.Equal(t, uint64(len(latencies)), h.Count)
+	assert.Equal(t, []uint64{3, 1, 0, 1, 0, 0, 0, 1}, h.Counts)
+	assert.GreaterOrEqual(t, h.Sum, 2*time.Second)
+	assert.Equal(t, time.Millisecond, h.Quantile(0.5))
+	assert.Equal(t, time.Second, h.Quantile(0.95))
+	assert.Greater(t, h.Mean(), time.Duration(0))
+
+	window := m.Reset()
+	assert.Equal(t, uint64(len(latencies)), window.Latency.Count)
+	assert.Zero(t, m.Latency().Count)
+	assert.Equal(t, time.Duration(0), m.Latency().Quantile(0.5))
+}
@@ -0,0 +1,80 @@
+// pkg/sl427/packet/assembler.go
+package packet
+
+import (
+	"fmt"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// assembly 一组正在接收的拆分帧
+type assembly struct {
+	next byte   // 期望的下一个拆分帧计数
+	data []byte // 已接收的数据域
+}
+
+// Assembler 将拆分帧(DIV=1)还原为一个完整的用户数据区
+// 按监测站地址、功能码和类型码区分不同的拆分序列,非并发安全
+type Assembler struct {
+	pending map[string]*assembly
+}
+
+// NewAssembler 创建拆分帧组装器
+func NewAssembler() *Assembler {
+	return &Assembler{pending: make(map[string]*assembly)}
+}
+
+// Add 加入一个数据包
+// 非拆分帧直接返回其用户数据区;拆分帧在收到最后一帧(DIVS=1)前返回nil,
+// 收到最后一帧时返回合并后的用户数据区,其控制域不再带拆分标志,
+// 密码和时间标签取自最后一帧。拆分帧计数不连续时丢弃之前的序列并返回错误,
+// 当前帧作为新序列的第一帧继续接收(DIVS=1的当前帧无法开始新序列,一并丢弃)。
+func (a *Assembler) Add(p *Packet) (*types.UserData, error) {
+	if p.UserData == nil {
+		return nil, fmt.Errorf("用户数据区未解析")
+	}
+	ud := p.UserData
+	if !ud.Control.IsDIV() {
+		return ud, nil
+	}
+
+	divs := ud.Control.DIVS()
+	if divs == 0 {
+		return nil, fmt.Errorf("无效的拆分帧计数: 0")
+	}
+
+	key := fmt.Sprintf("%X/%02X/%d", ud.Address.Bytes(), byte(ud.AFN), ud.Control.GetType())
+	seq, ok := a.pending[key]
+	if !ok {
+		seq = &assembly{next: divs}
+		a.pending[key] = seq
+	}
+	if divs != seq.next {
+		err := fmt.Errorf("拆分帧计数不连续: 期望%d,实际%d", seq.next, divs)
+		delete(a.pending, key)
+		if divs > 1 {
+			a.pending[key] = &assembly{
+				next: divs - 1,
+				data: append([]byte{}, ud.DataField...),
+			}
+		}
+		return nil, err
+	}
+	seq.data = append(seq.data, ud.DataField...)
+
+	if divs > 1 {
+		seq.next = divs - 1
+		return nil, nil
+	}
+
+	delete(a.pending, key)
+	merged := *ud
+	merged.Control = *types.NewControl(ud.Control.Bytes()[0] &^ types.DivBit)
+	merged.DataField = seq.data
+	return &merged, nil
+}
+
+// Pending 返回尚未接收完整的拆分序列数
+func (a *Assembler) Pending() int {
+	return len(a.pending)
+}
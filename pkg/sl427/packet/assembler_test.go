@@ -0,0 +1,163 @@
+package packet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// splitBuilder 返回携带指定数据域的自报帧构建器
+func splitBuilder(t *testing.T, data []byte) *FrameBuilder {
+	t.Helper()
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	return NewFrameBuilder().
+		Control(types.NewControl(types.DirBit | types.DataTypeQuality)).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Data(data).
+		TimeLabel(types.NewTimestamp(testTime))
+}
+
+func TestAssembler_LargePayload(t *testing.T) {
+	payload := make([]byte, 600)
+	for i := range payload {
+		payload[i] = byte(i % 100)
+	}
+
+	frames, err := splitBuilder(t, payload).BuildSplit()
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+
+	// 编码为字节流,经Reader读取后组装
+	var stream bytes.Buffer
+	c := codec.NewPacketCodec()
+	for _, f := range frames {
+		assert.LessOrEqual(t, len(f.UserDataRaw), types.MaxFrameLen)
+		raw, err := c.EncodePacket(f)
+		require.NoError(t, err)
+		stream.Write(raw)
+	}
+
+	r := NewReader(&stream, types.DefaultLogger)
+	a := NewAssembler()
+	var messages []*types.UserData
+	for {
+		frame, err := r.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		p, err := ParseUserData(frame)
+		require.NoError(t, err)
+
+		ud, err := a.Add(p)
+		require.NoError(t, err)
+		if ud != nil {
+			messages = append(messages, ud)
+		}
+	}
+
+	require.Len(t, messages, 1)
+	assert.Equal(t, payload, messages[0].DataField)
+	assert.False(t, messages[0].Control.IsDIV())
+	assert.Equal(t, byte(types.DataTypeQuality), messages[0].Control.GetType())
+	require.NotNil(t, messages[0].Tp)
+	assert.Equal(t, testTime.Unix(), messages[0].Tp.Seconds())
+	assert.Zero(t, a.Pending())
+}
+
+func TestAssembler_SmallPayload(t *testing.T) {
+	data := []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x25, 0x01, 0x00, 0x00}
+	frames, err := splitBuilder(t, data).BuildSplit()
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+
+	p := decodeBuilt(t, frames[0])
+	assert.False(t, p.UserData.Control.IsDIV())
+
+	ud, err := NewAssembler().Add(p)
+	require.NoError(t, err)
+	assert.Equal(t, data, ud.DataField)
+}
+
+func TestAssembler_OutOfOrder(t *testing.T) {
+	frames, err := splitBuilder(t, make([]byte, 600)).BuildSplit()
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+
+	a := NewAssembler()
+	ud, err := a.Add(decodeBuilt(t, frames[0]))
+	require.NoError(t, err)
+	assert.Nil(t, ud)
+	assert.Equal(t, 1, a.Pending())
+
+	// 跳过中间一帧
+	_, err = a.Add(decodeBuilt(t, frames[2]))
+	assert.Error(t, err)
+	assert.Zero(t, a.Pending())
+}
+
+func TestAssembler_RestartSequence(t *testing.T) {
+	first := make([]byte, 600)
+	second := bytes.Repeat([]byte{0x55}, 600)
+	framesA, err := splitBuilder(t, first).BuildSplit()
+	require.NoError(t, err)
+	framesB, err := splitBuilder(t, second).BuildSplit()
+	require.NoError(t, err)
+
+	a := NewAssembler()
+	_, err = a.Add(decodeBuilt(t, framesA[0]))
+	require.NoError(t, err)
+
+	// 前一序列中断,新序列的第一帧开始新的组装
+	_, err = a.Add(decodeBuilt(t, framesB[0]))
+	assert.Error(t, err)
+	assert.Equal(t, 1, a.Pending())
+
+	ud, err := a.Add(decodeBuilt(t, framesB[1]))
+	require.NoError(t, err)
+	assert.Nil(t, ud)
+	ud, err = a.Add(decodeBuilt(t, framesB[2]))
+	require.NoError(t, err)
+	require.NotNil(t, ud)
+	assert.Equal(t, second, ud.DataField)
+	assert.Zero(t, a.Pending())
+}
+
+func TestAssembler_NoTimeLabel(t *testing.T) {
+	payload := make([]byte, 600)
+	for i := range payload {
+		payload[i] = byte(i % 100)
+	}
+
+	// 在第一帧末尾放入形如时间标签的数据
+	frames, err := splitBuilder(t, payload).TimeLabel(nil).BuildSplit()
+	require.NoError(t, err)
+	chunk := len(decodeBuilt(t, frames[0]).UserData.DataField)
+	copy(payload[chunk-types.TimestampLen:chunk], types.NewTimestamp(testTime).Bytes())
+
+	frames, err = splitBuilder(t, payload).TimeLabel(nil).BuildSplit()
+	require.NoError(t, err)
+
+	a := NewAssembler()
+	var msg *types.UserData
+	for _, f := range frames {
+		p := decodeBuilt(t, f)
+		assert.Nil(t, p.UserData.Tp)
+		ud, err := a.Add(p)
+		require.NoError(t, err)
+		if ud != nil {
+			msg = ud
+		}
+	}
+	require.NotNil(t, msg)
+	assert.Equal(t, payload, msg.DataField)
+	assert.Nil(t, msg.Tp)
+}
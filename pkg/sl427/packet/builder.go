@@ -0,0 +1,202 @@
+// pkg/sl427/packet/builder.go
+package packet
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// FrameBuilder 以链式调用的方式构建帧
+// 各字段在Build时统一校验,任一必填字段缺失或用户数据区无效都会返回错误
+type FrameBuilder struct {
+	control *types.Control   // 控制域C
+	divs    *byte            // 拆分帧计数DIVS(可选,设置后控制域为2字节)
+	address types.Address    // 地址域A
+	afn     types.AFN        // 功能码AFN
+	hasAFN  bool             // 是否设置了功能码
+	data    []byte           // 数据域D
+	pw      *uint16          // 密码PW(可选)
+	tp      *types.TimeLabel // 时间标签Tp(可选)
+}
+
+// NewFrameBuilder 创建帧构建器
+func NewFrameBuilder() *FrameBuilder {
+	return &FrameBuilder{}
+}
+
+// Control 设置控制域
+func (b *FrameBuilder) Control(ctrl *types.Control) *FrameBuilder {
+	b.control = ctrl
+	return b
+}
+
+// DIV 设置拆分帧计数DIVS,控制域随之变为2字节(DIV位置1)
+// 按规约DIVS采用倒计数(255~1),1表示最后一帧
+func (b *FrameBuilder) DIV(divs byte) *FrameBuilder {
+	b.divs = &divs
+	return b
+}
+
+// Address 设置地址域
+func (b *FrameBuilder) Address(addr types.Address) *FrameBuilder {
+	b.address = addr
+	return b
+}
+
+// AFN 设置功能码
+func (b *FrameBuilder) AFN(afn types.AFN) *FrameBuilder {
+	b.afn = afn
+	b.hasAFN = true
+	return b
+}
+
+// Data 设置数据域
+func (b *FrameBuilder) Data(data []byte) *FrameBuilder {
+	b.data = data
+	return b
+}
+
+// Password 设置密码(下行报文需要)
+func (b *FrameBuilder) Password(pw uint16) *FrameBuilder {
+	b.pw = &pw
+	return b
+}
+
+// TimeLabel 设置时间标签
+func (b *FrameBuilder) TimeLabel(tp *types.TimeLabel) *FrameBuilder {
+	b.tp = tp
+	return b
+}
+
+// BuildUserData 校验并构建用户数据区
+func (b *FrameBuilder) BuildUserData() (*types.UserData, error) {
+	if b.control == nil {
+		return nil, fmt.Errorf("缺少控制域")
+	}
+	// 复制控制域,避免修改调用方传入的对象
+	ctrl := *b.control
+	if b.divs != nil {
+		if *b.divs == 0 {
+			return nil, fmt.Errorf("无效的拆分帧计数: 0(应该在1-255之间)")
+		}
+		ctrl.SetDIV(*b.divs)
+	}
+	if !b.hasAFN {
+		return nil, fmt.Errorf("缺少功能码")
+	}
+
+	userData := &types.UserData{
+		Control:   ctrl,
+		Address:   b.address,
+		AFN:       b.afn,
+		DataField: b.data,
+		PW:        b.pw,
+		Tp:        b.tp,
+	}
+	if err := userData.Validate(); err != nil {
+		return nil, err
+	}
+	return userData, nil
+}
+
+// Build 校验并构建可直接编码的帧
+func (b *FrameBuilder) Build() (*types.Frame, error) {
+	userData, err := b.BuildUserData()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := userData.Bytes()
+
+	return &types.Frame{
+		Head: types.Header{
+			StartFlag1: types.StartFlag,
+			Length:     byte(len(raw)),
+			StartFlag2: types.StartFlag,
+		},
+		UserDataRaw: raw,
+		CS:          codec.NewPacketCodec().Checksum(raw),
+		EndFlag:     types.EndFlag,
+	}, nil
+}
+
+// BuildSplit 构建数据域可能超出单帧长度的报文
+// 用户数据区不超过255字节时返回单个普通帧;否则按规约拆分为多个拆分帧(DIV=1),
+// 拆分帧计数DIVS从帧数倒计数到1,每帧都携带密码和时间标签。接收端可用Assembler还原。
+func (b *FrameBuilder) BuildSplit() ([]*types.Frame, error) {
+	if b.divs != nil {
+		return nil, fmt.Errorf("拆分帧计数由BuildSplit生成,不能预先设置")
+	}
+
+	// 计算拆分帧除数据域外的长度
+	probe := *b
+	probe.data = nil
+	probe.DIV(1)
+	userData, err := probe.BuildUserData()
+	if err != nil {
+		return nil, err
+	}
+	overhead := userData.EncodedLen()
+
+	// 去掉DIVS字节后能放下则不拆分
+	if overhead-1+len(b.data) <= types.MaxFrameLen {
+		frame, err := b.Build()
+		if err != nil {
+			return nil, err
+		}
+		return []*types.Frame{frame}, nil
+	}
+
+	// 计算各帧数据域的结束位置
+	// 不带时间标签时解码端会把形如时间标签的末尾7字节当作Tp,
+	// 此时向前缩短该帧,直到末尾不再形如时间标签
+	chunk := types.MaxFrameLen - overhead
+	var ends []int
+	for start := 0; start < len(b.data); {
+		end := start + chunk
+		if end >= len(b.data) {
+			end = len(b.data)
+		} else if b.tp == nil {
+			for types.IsTimeLabel(b.splitTail(end)) {
+				end--
+				if end-start <= types.TimestampLen {
+					return nil, fmt.Errorf("无法拆分: 数据域第%d字节起的内容均形如时间标签,请设置时间标签", start)
+				}
+			}
+		}
+		ends = append(ends, end)
+		start = end
+	}
+	count := len(ends)
+	if count > 255 {
+		return nil, fmt.Errorf("数据域过长: %d字节需要拆分为%d帧(最多255帧)", len(b.data), count)
+	}
+
+	frames := make([]*types.Frame, 0, count)
+	start := 0
+	for i, end := range ends {
+		part := *b
+		part.data = b.data[start:end]
+		part.DIV(byte(count - i))
+		frame, err := part.Build()
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+		start = end
+	}
+	return frames, nil
+}
+
+// splitTail 返回数据域截止到end的拆分帧用户数据区末尾7字节(数据域末尾加密码)
+func (b *FrameBuilder) splitTail(end int) []byte {
+	tail := make([]byte, 0, types.TimestampLen+2)
+	tail = append(tail, b.data[end-types.TimestampLen:end]...)
+	if b.pw != nil {
+		tail = binary.BigEndian.AppendUint16(tail, *b.pw)
+	}
+	return tail[len(tail)-types.TimestampLen:]
+}
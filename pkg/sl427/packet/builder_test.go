@@ -0,0 +1,240 @@
+package packet
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2024, 11, 10, 17, 30, 30, 0, time.Local)
+
+// decodeBuilt 编码构建出的帧并重新解码为Packet
+func decodeBuilt(t *testing.T, frame *types.Frame) *Packet {
+	t.Helper()
+	c := codec.NewPacketCodec()
+	raw, err := c.EncodePacket(frame)
+	require.NoError(t, err)
+	assert.Equal(t, frame.Raw(), raw)
+
+	decoded, err := c.DecodePacket(raw)
+	require.NoError(t, err)
+	p, err := ParseUserData(decoded)
+	require.NoError(t, err)
+	return p
+}
+
+func TestFrameBuilder_Upload(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	ctrl := types.NewControl(types.DirBit | types.DataTypeWaterLevel)
+	data := []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	frame, err := NewFrameBuilder().
+		Control(ctrl).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Data(data).
+		TimeLabel(types.NewTimestamp(testTime)).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, byte(len(frame.UserDataRaw)), frame.Head.Length)
+
+	p := decodeBuilt(t, frame)
+	assert.True(t, p.UserData.Control.IsUp())
+	assert.Equal(t, byte(types.DataTypeWaterLevel), p.UserData.Control.GetType())
+	assert.Equal(t, addr.Bytes(), p.UserData.Address.Bytes())
+	assert.Equal(t, types.AFNUpload, p.UserData.AFN)
+	assert.Equal(t, data, p.UserData.DataField)
+	assert.Nil(t, p.UserData.PW)
+	require.NotNil(t, p.UserData.Tp)
+	assert.Equal(t, testTime.Unix(), p.UserData.Tp.Seconds())
+}
+
+func TestFrameBuilder_Downlink(t *testing.T) {
+	addr, err := types.NewAddressV2([]byte{0x80, 0x00, 0x00, 0x01})
+	require.NoError(t, err)
+
+	frame, err := NewFrameBuilder().
+		Control(types.NewControl(types.CmdUpConfirm)).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Data([]byte{types.ModeUpload}).
+		Password(0x1234).
+		TimeLabel(types.NewTimestamp(testTime)).
+		Build()
+	require.NoError(t, err)
+
+	p := decodeBuilt(t, frame)
+	assert.False(t, p.UserData.Control.DIR())
+	assert.Equal(t, "80000001", p.UserData.Address.GetAddress())
+	assert.Equal(t, []byte{types.ModeUpload}, p.UserData.DataField)
+	require.NotNil(t, p.UserData.PW)
+	assert.Equal(t, uint16(0x1234), *p.UserData.PW)
+	require.NotNil(t, p.UserData.Tp)
+}
+
+func TestFrameBuilder_Invalid(t *testing.T) {
+	addr, err := types.NewAddressV2([]byte{0x80, 0x00, 0x00, 0x01})
+	require.NoError(t, err)
+
+	t.Run("missing address", func(t *testing.T) {
+		_, err := NewFrameBuilder().Control(types.NewControl(types.DirBit)).AFN(types.AFNUpload).Build()
+		assert.Error(t, err)
+	})
+
+	t.Run("downlink without password", func(t *testing.T) {
+		_, err := NewFrameBuilder().Control(types.NewControl(0)).Address(addr).AFN(types.AFNUpload).Build()
+		assert.Error(t, err)
+	})
+
+	t.Run("user data too long", func(t *testing.T) {
+		_, err := NewFrameBuilder().
+			Control(types.NewControl(types.DirBit)).
+			Address(addr).
+			AFN(types.AFNUpload).
+			Data(make([]byte, types.MaxFrameLen)).
+			Build()
+		assert.Error(t, err)
+	})
+}
+
+func TestFrameBuilder_RoundTrip(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	tp := types.NewTimestamp(testTime)
+
+	tests := []struct {
+		name    string
+		builder *FrameBuilder
+	}{
+		{
+			name: "upload without time label",
+			builder: NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{0x00, 0x12, 0x34, 0x00, 0x00, 0x00, 0x00}),
+		},
+		{
+			name: "upload with time label",
+			builder: NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{0x00, 0x12, 0x34, 0x00, 0x00, 0x00, 0x00}).TimeLabel(tp),
+		},
+		{
+			name: "downlink with password",
+			builder: NewFrameBuilder().Control(types.NewControl(types.CmdUpConfirm)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{types.ModeUpload}).Password(0x9999),
+		},
+		{
+			name: "downlink with password and time label",
+			builder: NewFrameBuilder().Control(types.NewControl(types.CmdUpConfirm)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{types.ModeUpload}).Password(0x9999).TimeLabel(tp),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.builder.BuildUserData()
+			require.NoError(t, err)
+
+			got, err := types.NewUserData(want.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, want.Bytes(), got.Bytes())
+			assert.Equal(t, want.DataField, got.DataField)
+			assert.Equal(t, want.PW, got.PW)
+			assert.Equal(t, want.Tp, got.Tp)
+		})
+	}
+}
+
+func TestFrameBuilder_ControlLength(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	data := []byte{0x00, 0x12, 0x34, 0x00, 0x00, 0x00, 0x00}
+
+	t.Run("single byte", func(t *testing.T) {
+		frame, err := NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+			Address(addr).AFN(types.AFNUpload).Data(data).Build()
+		require.NoError(t, err)
+
+		p := decodeBuilt(t, frame)
+		assert.False(t, p.UserData.Control.IsDIV())
+		assert.Equal(t, 1, p.UserData.Control.Length())
+		assert.Equal(t, data, p.UserData.DataField)
+	})
+
+	t.Run("split frame", func(t *testing.T) {
+		ctrl := types.NewControl(types.DirBit | types.DataTypeRain)
+		frame, err := NewFrameBuilder().Control(ctrl).DIV(3).
+			Address(addr).AFN(types.AFNUpload).Data(data).Build()
+		require.NoError(t, err)
+		assert.False(t, ctrl.IsDIV(), "builder must not modify the caller's control")
+
+		p := decodeBuilt(t, frame)
+		assert.True(t, p.UserData.Control.IsDIV())
+		assert.Equal(t, []byte{0xC1, 0x03}, p.UserData.Control.Bytes())
+		assert.Equal(t, data, p.UserData.DataField)
+		assert.Equal(t, frame.UserDataRaw, p.UserData.Bytes())
+	})
+
+	t.Run("DIV bit without count", func(t *testing.T) {
+		_, err := NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DivBit)).
+			Address(addr).AFN(types.AFNUpload).Build()
+		assert.Error(t, err)
+	})
+
+	t.Run("zero count", func(t *testing.T) {
+		_, err := NewFrameBuilder().Control(types.NewControl(types.DirBit)).DIV(0).
+			Address(addr).AFN(types.AFNUpload).Build()
+		assert.Error(t, err)
+	})
+}
+
+// sampleUploadHex 示例自报帧: 方式1地址123456/1号站,两个水位51.234m和-15.678m,
+// 采集时间2024-11-10 17:30:30
+const sampleUploadHex = "68 1A 68" + // 帧头,L=26
+	" 82" + // 控制域: 上行,水位参数
+	" 12 34 56 00 01" + // 地址域
+	" C0" + // 功能码: 自报实时数据
+	" 34 12 05 00 78 56 01 F0" + // 数据域: 两个水位
+	" 00 00 00 00" + // 设备状态
+	" 30 30 17 10 11 24 00" + // 时间标签
+	" 20 16" // CS和结束标识
+
+// sampleUploadFrame 由结构化字段构建示例自报帧
+func sampleUploadFrame(t *testing.T) *types.Frame {
+	t.Helper()
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	frame, err := NewFrameBuilder().
+		Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Data([]byte{
+			0x34, 0x12, 0x05, 0x00, // 51.234m
+			0x78, 0x56, 0x01, 0xF0, // -15.678m
+			0x00, 0x00, 0x00, 0x00, // 设备状态
+		}).
+		TimeLabel(types.NewTimestamp(testTime)).
+		Build()
+	require.NoError(t, err)
+	return frame
+}
+
+func TestFrameBuilder_SampleUpload(t *testing.T) {
+	want, err := hex.DecodeString(strings.ReplaceAll(sampleUploadHex, " ", ""))
+	require.NoError(t, err)
+
+	frame := sampleUploadFrame(t)
+	raw, err := codec.NewPacketCodec().EncodePacket(frame)
+	require.NoError(t, err)
+	assert.Equal(t, want, raw, "got % X", raw)
+
+	upload, err := decodeBuilt(t, frame).ParseUpload()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"SW":51.234,"SW2":-15.678}`, string(upload.Items))
+	assert.Equal(t, testTime.Unix(), upload.Timestamp.Seconds())
+}
@@ -0,0 +1,72 @@
+// pkg/sl427/packet/classify.go
+package packet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// FrameKind 数据包类别
+type FrameKind int
+
+const (
+	KindUnknown  FrameKind = iota // 无法识别
+	KindUpload                    // 终端机自报数据(实时、告警、人工置数、图片、电压)
+	KindQuery                     // 中心站下发的查询或命令
+	KindResponse                  // 认可帧(类型码为0)
+)
+
+// String 返回类别名称
+func (k FrameKind) String() string {
+	switch k {
+	case KindUpload:
+		return "自报"
+	case KindQuery:
+		return "查询"
+	case KindResponse:
+		return "认可"
+	default:
+		return "未知"
+	}
+}
+
+// Classify 根据传输方向、命令与类型码以及功能码判断数据包类别
+// 规约中没有独立的心跳帧,链路保持由自报或认可帧完成
+func Classify(p *Packet) (FrameKind, error) {
+	if p == nil || p.UserData == nil {
+		return KindUnknown, fmt.Errorf("用户数据区未解析")
+	}
+
+	ud := p.UserData
+	if ud.Control.GetType() == types.CmdUpConfirm {
+		return KindResponse, nil
+	}
+	if !ud.Control.DIR() {
+		return KindQuery, nil
+	}
+	if ud.AFN.IsValid() {
+		return KindUpload, nil
+	}
+	return KindUnknown, nil
+}
+
+// IsResponseTo 判断resp是否为req的应答
+// 两者传输方向相反,且监测站地址、功能码和命令与类型码相同时视为应答
+// (查询/响应帧上下行使用同一类型码,发送/确认帧均为0000B)。
+// SL427帧中没有流水号字段,同一监测站对同一命令的多次应答无法进一步区分,
+// 需要时由调用方按发送顺序自行对应
+func IsResponseTo(resp, req *Packet) bool {
+	if resp == nil || req == nil || resp.UserData == nil || req.UserData == nil {
+		return false
+	}
+	r, q := resp.UserData, req.UserData
+	if r.Address == nil || q.Address == nil {
+		return false
+	}
+	return r.Control.DIR() != q.Control.DIR() &&
+		r.AFN == q.AFN &&
+		r.Control.GetType() == q.Control.GetType() &&
+		bytes.Equal(r.Address.Bytes(), q.Address.Bytes())
+}
@@ -0,0 +1,116 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		builder *FrameBuilder
+		want    FrameKind
+	}{
+		{
+			name: "upload",
+			builder: NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{0x00, 0x12, 0x34}),
+			want: KindUpload,
+		},
+		{
+			name: "alarm upload",
+			builder: NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeAlarm)).
+				Address(addr).AFN(types.AFNAlarm).Data([]byte{0x00, 0x01, 0x00, 0x00}),
+			want: KindUpload,
+		},
+		{
+			name: "confirm",
+			builder: NewFrameBuilder().Control(types.NewControl(types.CmdUpConfirm)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{types.ModeUpload}).Password(0x1234),
+			want: KindResponse,
+		},
+		{
+			name: "query",
+			builder: NewFrameBuilder().Control(types.NewControl(types.DataTypeWaterLevel)).
+				Address(addr).AFN(types.AFNUpload).Password(0x1234),
+			want: KindQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := tt.builder.Build()
+			require.NoError(t, err)
+
+			kind, err := Classify(decodeBuilt(t, frame))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, kind, kind.String())
+		})
+	}
+
+	_, err = Classify(&Packet{})
+	assert.Error(t, err)
+}
+
+func TestIsResponseTo(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	other, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 2)
+	require.NoError(t, err)
+
+	build := func(b *FrameBuilder) *Packet {
+		frame, err := b.Build()
+		require.NoError(t, err)
+		return decodeBuilt(t, frame)
+	}
+	query := build(NewFrameBuilder().Control(types.NewControl(types.DataTypeWaterLevel)).
+		Address(addr).AFN(types.AFNUpload).Password(0x1234))
+	levels := []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	tests := []struct {
+		name string
+		resp *Packet
+		want bool
+	}{
+		{
+			name: "matching response",
+			resp: build(NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+				Address(addr).AFN(types.AFNUpload).Data(levels)),
+			want: true,
+		},
+		{
+			name: "other station",
+			resp: build(NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+				Address(other).AFN(types.AFNUpload).Data(levels)),
+		},
+		{
+			name: "other type code",
+			resp: build(NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+				Address(addr).AFN(types.AFNUpload).Data([]byte{0x00, 0x12, 0x34, 0x00, 0x00, 0x00, 0x00})),
+		},
+		{
+			name: "other AFN",
+			resp: build(NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+				Address(addr).AFN(types.AFNManualSet).Data(levels)),
+		},
+		{
+			name: "same direction",
+			resp: query,
+		},
+		{
+			name: "nil response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsResponseTo(tt.resp, query))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// pkg/sl427/packet/fieldmap.go
+package packet
+
+import (
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// Field 帧中一个字段的位置信息
+type Field struct {
+	Name   string // 字段名
+	Offset int    // 在原始帧中的偏移
+	Length int    // 字节数
+	Value  []byte // 原始字节
+}
+
+// FieldMap 解码帧并返回各字段在原始字节中的位置,供十六进制查看、调试日志等使用
+// 可选字段(用户功能码、密码、未识别尾部字节、时间标签)仅在存在时列出
+func FieldMap(data []byte) ([]Field, error) {
+	frame, err := codec.NewPacketCodec().DecodePacket(data)
+	if err != nil {
+		return nil, err
+	}
+	userData, err := types.NewUserData(frame.UserDataRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []Field
+	offset := 0
+	add := func(name string, n int) {
+		fields = append(fields, Field{Name: name, Offset: offset, Length: n, Value: data[offset : offset+n]})
+		offset += n
+	}
+
+	add("StartFlag1", 1)
+	add("Length", 1)
+	add("StartFlag2", 1)
+	add("Control", userData.Control.Length())
+	add("Address", types.AddressLen)
+	add("AFN", 1)
+	if userData.UserAFN != nil {
+		add("UserAFN", 1)
+	}
+	add("Data", len(userData.DataField))
+	if userData.PW != nil {
+		add("PW", 2)
+	}
+	if len(userData.Extra) > 0 {
+		add("Extra", len(userData.Extra))
+	}
+	if userData.Tp != nil {
+		add("Tp", types.TimeLabelLen)
+	}
+	add("CS", 1)
+	add("EndFlag", 1)
+
+	return fields, nil
+}
@@ -0,0 +1,53 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMap(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	data := []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00}
+	frame, err := NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+		Address(addr).AFN(types.AFNUpload).Data(data).TimeLabel(types.NewTimestamp(testTime)).Build()
+	require.NoError(t, err)
+	raw, err := codec.NewPacketCodec().EncodePacket(frame)
+	require.NoError(t, err)
+
+	fields, err := FieldMap(raw)
+	require.NoError(t, err)
+
+	want := []struct {
+		name           string
+		offset, length int
+	}{
+		{"StartFlag1", 0, 1},
+		{"Length", 1, 1},
+		{"StartFlag2", 2, 1},
+		{"Control", 3, 1},
+		{"Address", 4, 5},
+		{"AFN", 9, 1},
+		{"Data", 10, 8},
+		{"Tp", 18, 7},
+		{"CS", 25, 1},
+		{"EndFlag", 26, 1},
+	}
+	require.Len(t, fields, len(want))
+	for i, w := range want {
+		assert.Equal(t, w.name, fields[i].Name)
+		assert.Equal(t, w.offset, fields[i].Offset, w.name)
+		assert.Equal(t, w.length, fields[i].Length, w.name)
+	}
+	assert.Equal(t, addr.Bytes(), fields[4].Value)
+	assert.Equal(t, data, fields[6].Value)
+	assert.Equal(t, []byte{frame.CS}, fields[8].Value)
+	assert.Len(t, raw, fields[9].Offset+1)
+
+	_, err = FieldMap(raw[:len(raw)-1])
+	assert.Error(t, err)
+}
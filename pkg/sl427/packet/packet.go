@@ -2,18 +2,28 @@
 package packet
 
 import (
+	"bytes"
+	"fmt"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
 	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
 )
 
 // Packet 表示一个完整的数据包,关注语义而不是字节格式
+// 字段为兼容保留为导出,但数据包可能在多个goroutine间共享,
+// 应通过Header等访问方法读取,需要修改时使用WithFCB等方法获得副本,不要原地修改
+//
+// 三个字节字段的关系:
+//   - UserDataRaw 恰好是被解析为UserData的用户数据区字节,CS即按其计算,可用于转发或重新签名
+//   - UserData 是UserDataRaw的语义视图,对本包构建的帧,UserData.Bytes()与UserDataRaw逐字节相等
+//   - DataRaw 是整帧字节: 帧头 + UserDataRaw + CS + 结束标识
 type Packet struct {
 	Head        types.Header    // 帧头
-	UserDataRaw []byte          // 数据域
+	UserDataRaw []byte          // 用户数据区原始字节
 	UserData    *types.UserData // 用户数据区
 	CS          byte            // 校验码(CRC)
 	EndFlag     byte            // 帧结束标识
-	DataRaw     []byte          // 原始数据
-
+	DataRaw     []byte          // 整帧原始字节
 }
 
 // ParseUserData 解析用户数据区
@@ -34,3 +44,101 @@ func ParseUserData(frame *types.Frame) (*Packet, error) {
 		DataRaw:     frame.Raw(),
 	}, nil
 }
+
+// NewPacketFromUserData 直接由用户数据区字节创建数据包
+// 帧头长度、CS和结束标识按用户数据区重新生成,适用于不经过帧格式传输用户数据的场合
+func NewPacketFromUserData(userData []byte) (*Packet, error) {
+	if len(userData) > types.MaxFrameLen {
+		return nil, fmt.Errorf("用户数据区过长: %d(最大%d)", len(userData), types.MaxFrameLen)
+	}
+	frame := &types.Frame{
+		Head: types.Header{
+			StartFlag1: types.StartFlag,
+			Length:     byte(len(userData)),
+			StartFlag2: types.StartFlag,
+		},
+		UserDataRaw: userData,
+		CS:          codec.NewPacketCodec().Checksum(userData),
+		EndFlag:     types.EndFlag,
+	}
+	return ParseUserData(frame)
+}
+
+// Header 返回帧头的副本
+func (p *Packet) Header() types.Header {
+	return p.Head
+}
+
+// FCB 返回控制域中的帧计数位,用户数据区未解析时返回0
+func (p *Packet) FCB() byte {
+	if p.UserData == nil {
+		return 0
+	}
+	return p.UserData.Control.FCB()
+}
+
+// WithFCB 返回帧计数位为fcb的数据包副本,并重新计算CS和原始数据
+// 按规约重发时启动站将FCB减1,原数据包保持不变;用户数据区未解析时原样返回副本
+func (p *Packet) WithFCB(fcb byte) *Packet {
+	if p.UserData == nil {
+		cp := *p
+		return &cp
+	}
+	userData := *p.UserData
+	userData.Control.SetFCB(fcb)
+
+	raw := userData.Bytes()
+	cp := &Packet{
+		Head:        p.Head,
+		UserDataRaw: raw,
+		UserData:    &userData,
+		CS:          codec.NewPacketCodec().Checksum(raw),
+		EndFlag:     p.EndFlag,
+	}
+	cp.DataRaw = (&types.Frame{Head: cp.Head, UserDataRaw: raw, CS: cp.CS, EndFlag: cp.EndFlag}).Raw()
+	return cp
+}
+
+// PacketsEqual 按语义比较两个数据包是否相同
+// 比较帧头、CS、结束标识以及用户数据区的各个字段,不依赖原始字节切片
+func PacketsEqual(a, b *Packet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Head == b.Head &&
+		a.CS == b.CS &&
+		a.EndFlag == b.EndFlag &&
+		userDataEqual(a.UserData, b.UserData)
+}
+
+// userDataEqual 按字段比较两个用户数据区
+func userDataEqual(a, b *types.UserData) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !bytes.Equal(a.Control.Bytes(), b.Control.Bytes()) || a.AFN != b.AFN {
+		return false
+	}
+	if (a.Address == nil) != (b.Address == nil) ||
+		(a.Address != nil && !bytes.Equal(a.Address.Bytes(), b.Address.Bytes())) {
+		return false
+	}
+	if !bytePtrEqual(a.UserAFN, b.UserAFN) {
+		return false
+	}
+	if (a.PW == nil) != (b.PW == nil) || (a.PW != nil && *a.PW != *b.PW) {
+		return false
+	}
+	if (a.Tp == nil) != (b.Tp == nil) || (a.Tp != nil && *a.Tp != *b.Tp) {
+		return false
+	}
+	return bytes.Equal(a.DataField, b.DataField) && bytes.Equal(a.Extra, b.Extra)
+}
+
+// bytePtrEqual 比较两个可选字节
+func bytePtrEqual(a, b *byte) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,71 @@
+// pkg/sl427/packet/packet_reader.go
+package packet
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// maxDatagramLen 单个数据报的最大长度
+const maxDatagramLen = 65535
+
+// PacketReader 从面向数据报的连接(如UDP)中读取SL427帧
+// 每个数据报可能包含一个或多个首尾相接的帧,逐帧返回并附带来源地址
+type PacketReader struct {
+	conn    net.PacketConn
+	logger  types.Logger
+	codec   *codec.PacketCodec
+	buf     []byte
+	pending []*types.Frame // 当前数据报中尚未返回的帧
+	addr    net.Addr       // 当前数据报的来源地址
+}
+
+// NewPacketReader 创建数据报帧读取器
+// opts为编解码器选项,设置了WithStartFlag/WithEndFlag时按衍生协议的标识分帧和解码
+func NewPacketReader(conn net.PacketConn, logger types.Logger, opts ...codec.Option) *PacketReader {
+	return &PacketReader{
+		conn:   conn,
+		logger: logger,
+		codec:  codec.NewPacketCodec(opts...),
+		buf:    make([]byte, maxDatagramLen),
+	}
+}
+
+// log 返回日志实现,未设置时使用types.DefaultLogger
+func (r *PacketReader) log() types.Logger {
+	if r.logger == nil {
+		return types.DefaultLogger
+	}
+	return r.logger
+}
+
+// ReadFrame 读取下一帧及其来源地址
+// 数据报中部分帧解码失败时记录日志并返回已成功解码的帧;
+// 整个数据报都无法解码时返回错误,读取器仍可继续使用;
+// 空数据报(合法的UDP数据报)不含任何帧,直接读取下一个
+func (r *PacketReader) ReadFrame() (*types.Frame, net.Addr, error) {
+	for len(r.pending) == 0 {
+		n, addr, err := r.conn.ReadFrom(r.buf)
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取数据报失败: %w", err)
+		}
+
+		// 复制数据报,解码出的帧引用该副本,不受下次读取覆盖
+		datagram := append([]byte(nil), r.buf[:n]...)
+		frames, err := r.codec.DecodeFrames(datagram)
+		if err != nil {
+			if len(frames) == 0 {
+				return nil, addr, fmt.Errorf("解码数据报失败(来自%s): %w", addr, err)
+			}
+			r.log().Printf("数据报部分解码失败(来自%s): %v", addr, err)
+		}
+		r.pending, r.addr = frames, addr
+	}
+
+	frame := r.pending[0]
+	r.pending = r.pending[1:]
+	return frame, r.addr, nil
+}
@@ -0,0 +1,121 @@
+package packet
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDatagram 模拟连接中的一个数据报
+type mockDatagram struct {
+	data []byte
+	addr net.Addr
+}
+
+// mockPacketConn 依次返回预置数据报的net.PacketConn
+type mockPacketConn struct {
+	datagrams []mockDatagram
+}
+
+func (c *mockPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if len(c.datagrams) == 0 {
+		return 0, nil, io.EOF
+	}
+	d := c.datagrams[0]
+	c.datagrams = c.datagrams[1:]
+	return copy(p, d.data), d.addr, nil
+}
+
+func (c *mockPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) { return len(p), nil }
+func (c *mockPacketConn) Close() error                                 { return nil }
+func (c *mockPacketConn) LocalAddr() net.Addr                          { return &net.UDPAddr{} }
+func (c *mockPacketConn) SetDeadline(t time.Time) error                { return nil }
+func (c *mockPacketConn) SetReadDeadline(t time.Time) error            { return nil }
+func (c *mockPacketConn) SetWriteDeadline(t time.Time) error           { return nil }
+
+func TestPacketReader(t *testing.T) {
+	first := encodeUpload(t, []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00})
+	second := encodeUpload(t, []byte{0x00, 0x01})
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 5000}
+	other := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 11), Port: 5000}
+
+	conn := &mockPacketConn{datagrams: []mockDatagram{
+		{data: append(append([]byte{}, first...), second...), addr: src},
+		{data: []byte{0x00, 0x01, 0x02}, addr: other},
+		{data: second, addr: other},
+	}}
+	r := NewPacketReader(conn, types.DefaultLogger)
+
+	frame, addr, err := r.ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, src, addr)
+	assert.Equal(t, first, frame.Raw())
+
+	frame, addr, err = r.ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, src, addr)
+	assert.Equal(t, second, frame.Raw())
+
+	// 无法解码的数据报返回错误,读取器可继续使用
+	_, addr, err = r.ReadFrame()
+	assert.Error(t, err)
+	assert.Equal(t, other, addr)
+
+	frame, addr, err = r.ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, other, addr)
+	assert.Equal(t, second, frame.Raw())
+
+	_, _, err = r.ReadFrame()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestPacketReader_NilLogger(t *testing.T) {
+	good := encodeUpload(t, []byte{0x00, 0x01})
+	conn := &mockPacketConn{datagrams: []mockDatagram{
+		{data: append(append([]byte{}, good...), 0x00), addr: &net.UDPAddr{}},
+	}}
+
+	assert.NotPanics(t, func() {
+		frame, _, err := NewPacketReader(conn, nil).ReadFrame()
+		require.NoError(t, err)
+		assert.Equal(t, good, frame.Raw())
+	})
+}
+
+func TestPacketReader_EmptyDatagram(t *testing.T) {
+	good := encodeUpload(t, []byte{0x00, 0x01})
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 5000}
+	conn := &mockPacketConn{datagrams: []mockDatagram{
+		{data: []byte{}, addr: src},
+		{data: good, addr: src},
+	}}
+	r := NewPacketReader(conn, types.DefaultLogger)
+
+	assert.NotPanics(t, func() {
+		frame, addr, err := r.ReadFrame()
+		require.NoError(t, err)
+		assert.Equal(t, src, addr)
+		assert.Equal(t, good, frame.Raw())
+	})
+
+	_, _, err := r.ReadFrame()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestPacketReader_CustomFlags(t *testing.T) {
+	opts := []codec.Option{codec.WithStartFlag(0x7E), codec.WithEndFlag(0x7F)}
+	good := encodeUpload(t, []byte{0x00, 0x01}, opts...)
+	conn := &mockPacketConn{datagrams: []mockDatagram{{data: good, addr: &net.UDPAddr{}}}}
+
+	frame, _, err := NewPacketReader(conn, types.DefaultLogger, opts...).ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x7E), frame.Head.StartFlag1)
+	assert.Equal(t, byte(0x7F), frame.EndFlag)
+}
@@ -0,0 +1,115 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacket_WithFCB(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	ctrl := types.NewControl(types.DirBit | types.DataTypeRain)
+	ctrl.SetFCB(3)
+	frame, err := NewFrameBuilder().Control(ctrl).Address(addr).AFN(types.AFNUpload).
+		Data([]byte{0x00, 0x12, 0x34}).Build()
+	require.NoError(t, err)
+
+	orig := decodeBuilt(t, frame)
+	origRaw := append([]byte{}, orig.DataRaw...)
+
+	retry := orig.WithFCB(2)
+	assert.Equal(t, byte(2), retry.FCB())
+	assert.Equal(t, byte(3), orig.FCB(), "original must not be mutated")
+	assert.Equal(t, origRaw, orig.DataRaw)
+	assert.Equal(t, orig.Header(), retry.Header())
+
+	// 副本可以直接解码,CS已重新计算
+	decoded, err := codec.NewPacketCodec().DecodePacket(retry.DataRaw)
+	require.NoError(t, err)
+	assert.Equal(t, retry.CS, decoded.CS)
+	assert.NotEqual(t, orig.CS, retry.CS)
+}
+
+func TestPacket_FCBWithoutUserData(t *testing.T) {
+	p := &Packet{DataRaw: []byte{0x68, 0x00, 0x68}}
+	assert.Zero(t, p.FCB())
+
+	cp := p.WithFCB(2)
+	require.NotNil(t, cp)
+	assert.NotSame(t, p, cp)
+	assert.Nil(t, cp.UserData)
+	assert.Equal(t, p.DataRaw, cp.DataRaw)
+}
+
+func TestPacketsEqual(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	build := func(data []byte) *Packet {
+		frame, err := NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+			Address(addr).AFN(types.AFNUpload).Data(data).TimeLabel(types.NewTimestamp(testTime)).Build()
+		require.NoError(t, err)
+		return decodeBuilt(t, frame)
+	}
+
+	a := build([]byte{0x00, 0x12, 0x34})
+	b := build([]byte{0x00, 0x12, 0x34})
+	assert.True(t, PacketsEqual(a, b))
+
+	// 原始字节不参与比较
+	b.DataRaw = nil
+	assert.True(t, PacketsEqual(a, b))
+
+	assert.False(t, PacketsEqual(a, build([]byte{0x00, 0x12, 0x35})))
+	assert.False(t, PacketsEqual(a, a.WithFCB(1)))
+	assert.False(t, PacketsEqual(a, nil))
+	assert.True(t, PacketsEqual(nil, nil))
+}
+
+func TestNewPacketFromUserData(t *testing.T) {
+	frame := sampleUploadFrame(t)
+
+	p, err := NewPacketFromUserData(frame.UserDataRaw)
+	require.NoError(t, err)
+	assert.Equal(t, frame.Head, p.Header())
+	assert.Equal(t, frame.CS, p.CS)
+	assert.Equal(t, frame.Raw(), p.DataRaw)
+	assert.True(t, PacketsEqual(decodeBuilt(t, frame), p))
+
+	_, err = NewPacketFromUserData([]byte{0x82, 0x12})
+	assert.Error(t, err)
+	_, err = NewPacketFromUserData(make([]byte, types.MaxFrameLen+1))
+	assert.Error(t, err)
+}
+
+func TestPacket_UserDataRaw(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	tp := types.NewTimestamp(testTime)
+
+	tests := []struct {
+		name  string
+		build func() (*types.Frame, error)
+	}{
+		{"sample upload", func() (*types.Frame, error) { return sampleUploadFrame(t), nil }},
+		{"downlink with password", NewFrameBuilder().Control(types.NewControl(types.CmdUpConfirm)).
+			Address(addr).AFN(types.AFNUpload).Data([]byte{types.ModeUpload}).Password(0x1234).TimeLabel(tp).Build},
+		{"split frame", NewFrameBuilder().Control(types.NewControl(types.DirBit | types.DataTypeRain)).DIV(2).
+			Address(addr).AFN(types.AFNUpload).Data([]byte{0x00, 0x12, 0x34}).Build},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := tt.build()
+			require.NoError(t, err)
+
+			p := decodeBuilt(t, frame)
+			assert.Equal(t, frame.UserDataRaw, p.UserDataRaw)
+			assert.Equal(t, p.UserDataRaw, p.UserData.Bytes())
+			assert.Equal(t, p.DataRaw[3:len(p.DataRaw)-2], p.UserDataRaw)
+		})
+	}
+}
@@ -4,29 +4,83 @@ package packet
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 
 	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/metrics"
 	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
 )
 
+// LengthMode 长度字段的处理模式
+type LengthMode int
+
+const (
+	// LengthTrust 完全信任长度字段(默认),按L读取后再检查结束标识,
+	// 长度字段损坏时已读取的字节不会退回
+	LengthTrust LengthMode = iota
+	// LengthVerify 先预读整帧并确认长度字段指向的位置确为结束标识,
+	// 否则视为长度损坏,从下一个起始标识重新同步,不会误消费后续帧的字节
+	LengthVerify
+)
+
+// ErrTruncatedFrame 连接在帧中途关闭,已读取部分字节但帧不完整
+// 帧之间的正常关闭返回包装了io.EOF的错误,不会返回该错误
+var ErrTruncatedFrame = errors.New("帧不完整")
+
 // FrameReader 从io.Reader中读取SL427帧
 type Reader struct {
-	reader *bufio.Reader
-	logger types.Logger
+	reader     *bufio.Reader
+	logger     types.Logger
+	codec      *codec.PacketCodec // 解码帧,起止标识也按其设置扫描
+	metrics    *metrics.Metrics   // 可选,设置后记录读取到的帧数和字节数
+	lengthMode LengthMode         // 长度字段处理模式
 }
 
 // NewFrameReader 创建帧读取器
-func NewReader(r io.Reader, logger types.Logger) *Reader {
+// opts为编解码器选项,设置了WithStartFlag/WithEndFlag时按衍生协议的标识分帧和解码
+func NewReader(r io.Reader, logger types.Logger, opts ...codec.Option) *Reader {
 	return &Reader{
 		reader: bufio.NewReader(r),
 		logger: logger,
+		codec:  codec.NewPacketCodec(opts...),
 	}
 }
 
+// log 返回日志实现,未设置时使用types.DefaultLogger
+func (r *Reader) log() types.Logger {
+	if r.logger == nil {
+		return types.DefaultLogger
+	}
+	return r.logger
+}
+
+// packetCodec 返回编解码器,未设置时使用默认的SL427编解码器
+func (r *Reader) packetCodec() *codec.PacketCodec {
+	if r.codec == nil {
+		r.codec = codec.NewPacketCodec()
+	}
+	return r.codec
+}
+
+// SetMetrics 设置监控指标,每成功读取一帧记录一次接收及其字节数
+func (r *Reader) SetMetrics(m *metrics.Metrics) {
+	r.metrics = m
+}
+
+// SetLengthMode 设置长度字段的处理模式
+func (r *Reader) SetLengthMode(mode LengthMode) {
+	r.lengthMode = mode
+}
+
 func (r *Reader) ReadFrame() (*types.Frame, error) {
+	if r.lengthMode == LengthVerify {
+		return r.readFrameVerified()
+	}
+
 	var buf bytes.Buffer
+	startFlag, endFlag := r.packetCodec().StartFlag(), r.packetCodec().EndFlag()
 
 	// 1. 查找起始标识
 	startByte, err := r.reader.ReadByte()
@@ -35,18 +89,18 @@ func (r *Reader) ReadFrame() (*types.Frame, error) {
 	}
 
 	// 寻找帧头
-	if startByte != types.StartFlag {
+	if startByte != startFlag {
 		for {
 			b, err := r.reader.ReadByte()
 			if err != nil {
 				return nil, fmt.Errorf("寻找起始标识时出错: %w", err)
 			}
-			if b == types.StartFlag {
+			if b == startFlag {
 				startByte = b
 				break
 			}
 			// 记录跳过的无效字节
-			r.logger.Printf("跳过无效字节: 0x%02X(期望为0x68)", b)
+			r.log().Printf("跳过无效字节: 0x%02X(期望为0x%02X)", b, startFlag)
 		}
 	}
 	buf.WriteByte(startByte)
@@ -54,6 +108,9 @@ func (r *Reader) ReadFrame() (*types.Frame, error) {
 	// 2. 读取长度字节
 	length, err := r.reader.ReadByte()
 	if err != nil {
+		if err == io.EOF {
+			return nil, r.truncated(buf.Bytes(), 0)
+		}
 		return nil, fmt.Errorf("读取长度字节失败: %w", err)
 	}
 	// 验证长度的合法性
@@ -65,12 +122,15 @@ func (r *Reader) ReadFrame() (*types.Frame, error) {
 	// 3. 读取第二个起始标识
 	startByte2, err := r.reader.ReadByte()
 	if err != nil {
+		if err == io.EOF {
+			return nil, r.truncated(buf.Bytes(), int(length)+5)
+		}
 		return nil, fmt.Errorf("读取第二个起始标识失败: %w", err)
 	}
 	buf.WriteByte(startByte2)
 
-	if startByte2 != types.StartFlag {
-		return nil, fmt.Errorf("第二个起始标识错误: 0x%02X(期望值为0x68)", startByte2)
+	if startByte2 != startFlag {
+		return nil, fmt.Errorf("第二个起始标识错误: 0x%02X(期望值为0x%02X)", startByte2, startFlag)
 	}
 
 	// 4. 读取用户数据区和校验码
@@ -78,27 +138,112 @@ func (r *Reader) ReadFrame() (*types.Frame, error) {
 	data := make([]byte, remainingBytes)
 	n, err := io.ReadFull(r.reader, data)
 	if err != nil {
-		if err == io.ErrUnexpectedEOF {
-			return nil, fmt.Errorf("数据不完整: 期望%d字节,实际读取%d字节", remainingBytes, n)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			buf.Write(data[:n])
+			return nil, r.truncated(buf.Bytes(), int(length)+5)
 		}
 		return nil, fmt.Errorf("读取剩余数据失败: %w", err)
 	}
 
 	// 检查结束标识
-	if data[len(data)-1] != types.EndFlag {
-		return nil, fmt.Errorf("结束标识错误: 0x%02X(期望值为0x16)", data[len(data)-1])
+	if data[len(data)-1] != endFlag {
+		return nil, fmt.Errorf("结束标识错误: 0x%02X(期望值为0x%02X)", data[len(data)-1], endFlag)
 	}
 
 	buf.Write(data)
 
-	// 输出完整的数据包内容(用于调试)
-	rawData := buf.Bytes()
-	r.logger.Printf("读取到数据包: % X", rawData)
+	return r.decode(buf.Bytes())
+}
 
-	codec := codec.NewPacketCodec()
-	frame, err := codec.DecodePacket(rawData)
+// readFrameVerified 以LengthVerify模式读取一帧
+// 每次只预读不消费,确认帧头和结束标识都正确后才取出整帧,
+// 否则丢弃当前起始字节继续寻找下一个起始标识
+func (r *Reader) readFrameVerified() (*types.Frame, error) {
+	startFlag, endFlag := r.packetCodec().StartFlag(), r.packetCodec().EndFlag()
+	for {
+		// 1. 寻找起始标识
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("寻找起始标识时出错: %w", err)
+		}
+		if b != startFlag {
+			r.log().Printf("跳过无效字节: 0x%02X(期望为0x%02X)", b, startFlag)
+			continue
+		}
+		if err := r.reader.UnreadByte(); err != nil {
+			return nil, fmt.Errorf("回退起始标识失败: %w", err)
+		}
+
+		// 2. 预读帧头
+		head, err := r.reader.Peek(3)
+		if err != nil {
+			if err == io.EOF {
+				return nil, r.truncated(head, 0)
+			}
+			return nil, fmt.Errorf("读取帧头失败: %w", err)
+		}
+		length := head[1]
+		if length == 0 || head[2] != startFlag {
+			r.skipStart("帧头无效: % X", head)
+			continue
+		}
+
+		// 3. 预读整帧并检查长度字段指向的结束标识
+		frameLen := int(length) + 5
+		data, err := r.reader.Peek(frameLen)
+		if err != nil {
+			if err == io.EOF {
+				return nil, r.truncated(data, frameLen)
+			}
+			return nil, fmt.Errorf("读取剩余数据失败: %w", err)
+		}
+		if data[frameLen-1] != endFlag {
+			r.skipStart("长度字段疑似损坏: L=%d处不是结束标识(0x%02X)", length, data[frameLen-1])
+			continue
+		}
+
+		rawData := make([]byte, frameLen)
+		copy(rawData, data)
+		if _, err := r.reader.Discard(frameLen); err != nil {
+			return nil, fmt.Errorf("读取数据失败: %w", err)
+		}
+		return r.decode(rawData)
+	}
+}
+
+// truncated 记录连接在帧中途关闭的情况并返回ErrTruncatedFrame
+// want为期望的整帧长度,长度字段尚未读到时为0
+func (r *Reader) truncated(partial []byte, want int) error {
+	r.log().Printf("连接在帧中途关闭,丢弃%d字节的不完整帧", len(partial))
+	types.Debugf(r.log(), "不完整的帧: % X", partial)
+	if r.metrics != nil {
+		r.metrics.RecordTruncated()
+	}
+	if want == 0 {
+		return fmt.Errorf("%w: 已读取%d字节", ErrTruncatedFrame, len(partial))
+	}
+	return fmt.Errorf("%w: 期望%d字节,实际读取%d字节", ErrTruncatedFrame, want, len(partial))
+}
+
+// skipStart 丢弃当前的起始字节并记录原因,用于重新同步
+func (r *Reader) skipStart(format string, v ...interface{}) {
+	r.log().Printf("重新同步,"+format, v...)
+	r.reader.Discard(1)
+}
+
+// decode 解码一个完整的数据包
+func (r *Reader) decode(rawData []byte) (*types.Frame, error) {
+	// 完整的数据包内容只在调试级别输出,错误中不携带原始数据
+	frame, err := r.packetCodec().DecodePacket(rawData)
 	if err != nil {
-		return nil, fmt.Errorf("解码数据包失败[原始数据:% X]: %w", rawData, err)
+		types.Debugf(r.log(), "解码失败的数据包: % X", rawData)
+		return nil, fmt.Errorf("解码数据包失败: %w", err)
+	}
+	types.Debugf(r.log(), "读取到数据包: % X", rawData)
+
+	if r.metrics != nil {
+		r.metrics.RecordReceive()
+		r.metrics.RecordReceiveBytes(len(rawData))
 	}
 
 	return frame, nil
@@ -0,0 +1,209 @@
+package packet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/codec"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/metrics"
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeUpload 构建并编码一个上行自报帧,opts为编码使用的编解码器选项
+func encodeUpload(t *testing.T, data []byte, opts ...codec.Option) []byte {
+	t.Helper()
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	frame, err := NewFrameBuilder().
+		Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Data(data).
+		Build()
+	require.NoError(t, err)
+	raw, err := codec.NewPacketCodec(opts...).EncodePacket(frame)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestReader_Metrics(t *testing.T) {
+	first := encodeUpload(t, []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00})
+	second := encodeUpload(t, []byte{0x00, 0x01})
+
+	m := metrics.NewMetrics()
+	r := NewReader(bytes.NewReader(append(append([]byte{}, first...), second...)), types.DefaultLogger)
+	r.SetMetrics(m)
+
+	for i := 0; i < 2; i++ {
+		_, err := r.ReadFrame()
+		require.NoError(t, err)
+	}
+
+	s := m.Snapshot()
+	assert.Equal(t, uint64(2), s.PacketsReceived)
+	assert.Equal(t, uint64(len(first)+len(second)), s.BytesReceived)
+}
+
+func TestReader_LengthVerify(t *testing.T) {
+	corrupt := encodeUpload(t, []byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00})
+	corrupt[1] -= 3 // 长度字段损坏
+	good := encodeUpload(t, []byte{0x00, 0x01})
+	stream := append(append([]byte{}, corrupt...), good...)
+
+	t.Run("verify resyncs to the next frame", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(stream), types.DefaultLogger)
+		r.SetLengthMode(LengthVerify)
+
+		frame, err := r.ReadFrame()
+		require.NoError(t, err)
+		assert.Equal(t, good, frame.Raw())
+	})
+
+	t.Run("trust consumes the corrupt length", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(stream), types.DefaultLogger)
+
+		_, err := r.ReadFrame()
+		assert.Error(t, err)
+	})
+
+	t.Run("verify reports truncated frame", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(good[:len(good)-2]), types.DefaultLogger)
+		r.SetLengthMode(LengthVerify)
+
+		_, err := r.ReadFrame()
+		assert.Error(t, err)
+	})
+}
+
+func TestReader_EOF(t *testing.T) {
+	good := encodeUpload(t, []byte{0x00, 0x01})
+
+	for _, mode := range []LengthMode{LengthTrust, LengthVerify} {
+		t.Run("clean close between frames", func(t *testing.T) {
+			m := metrics.NewMetrics()
+			r := NewReader(bytes.NewReader(good), types.DefaultLogger)
+			r.SetLengthMode(mode)
+			r.SetMetrics(m)
+
+			_, err := r.ReadFrame()
+			require.NoError(t, err)
+			_, err = r.ReadFrame()
+			assert.ErrorIs(t, err, io.EOF)
+			assert.NotErrorIs(t, err, ErrTruncatedFrame)
+			assert.Zero(t, m.Snapshot().PacketsTruncated)
+		})
+
+		t.Run("close mid-frame", func(t *testing.T) {
+			for _, n := range []int{1, 2, 3, len(good) - 1} {
+				m := metrics.NewMetrics()
+				r := NewReader(bytes.NewReader(good[:n]), types.DefaultLogger)
+				r.SetLengthMode(mode)
+				r.SetMetrics(m)
+
+				_, err := r.ReadFrame()
+				assert.ErrorIs(t, err, ErrTruncatedFrame, "%d bytes", n)
+				assert.Equal(t, uint64(1), m.Snapshot().PacketsTruncated)
+			}
+		})
+	}
+}
+
+func TestReader_NilLogger(t *testing.T) {
+	good := encodeUpload(t, []byte{0x00, 0x01})
+	// 前导无效字节会触发日志输出
+	stream := append([]byte{0x00, 0x01}, good...)
+
+	assert.NotPanics(t, func() {
+		r := NewReader(bytes.NewReader(stream), nil)
+		frame, err := r.ReadFrame()
+		require.NoError(t, err)
+		assert.Equal(t, good, frame.Raw())
+	})
+
+	assert.NotPanics(t, func() {
+		r := &Reader{reader: bufio.NewReader(bytes.NewReader(stream[:len(stream)-1]))}
+		_, err := r.ReadFrame()
+		assert.ErrorIs(t, err, ErrTruncatedFrame)
+	})
+}
+
+// levelLogger 记录日志输出的分级日志,debug为false时丢弃调试日志
+type levelLogger struct {
+	debug bool
+	lines []string
+}
+
+func (l *levelLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *levelLogger) Debugf(format string, v ...interface{}) {
+	if l.debug {
+		l.Printf(format, v...)
+	}
+}
+
+func TestReader_RawDumpDebugOnly(t *testing.T) {
+	good := encodeUpload(t, []byte{0x00, 0x01})
+	dump := fmt.Sprintf("% X", good)
+
+	for _, debug := range []bool{false, true} {
+		l := &levelLogger{debug: debug}
+		r := NewReader(bytes.NewReader(append([]byte{0x00, 0x01}, good...)), l)
+		_, err := r.ReadFrame()
+		require.NoError(t, err)
+
+		joined := strings.Join(l.lines, "\n")
+		assert.Contains(t, joined, "跳过无效字节", "operational messages are always logged")
+		if debug {
+			assert.Contains(t, joined, dump)
+		} else {
+			assert.NotContains(t, joined, dump)
+		}
+	}
+}
+
+func TestReader_DecodeErrorWithoutDump(t *testing.T) {
+	bad := encodeUpload(t, []byte{0x00, 0x01})
+	bad[len(bad)-2] ^= 0xFF // CS错误
+	dump := fmt.Sprintf("% X", bad)
+
+	for _, debug := range []bool{false, true} {
+		l := &levelLogger{debug: debug}
+		_, err := NewReader(bytes.NewReader(bad), l).ReadFrame()
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), dump, "errors must not carry the raw frame")
+
+		joined := strings.Join(l.lines, "\n")
+		if debug {
+			assert.Contains(t, joined, dump)
+		} else {
+			assert.NotContains(t, joined, dump)
+		}
+	}
+}
+
+func TestReader_CustomFlags(t *testing.T) {
+	opts := []codec.Option{codec.WithStartFlag(0x7E), codec.WithEndFlag(0x7F)}
+	good := encodeUpload(t, []byte{0x00, 0x01}, opts...)
+	stream := append([]byte{0x68, 0x00}, good...)
+
+	for _, mode := range []LengthMode{LengthTrust, LengthVerify} {
+		r := NewReader(bytes.NewReader(stream), types.DefaultLogger, opts...)
+		r.SetLengthMode(mode)
+		frame, err := r.ReadFrame()
+		require.NoError(t, err, "mode %d", mode)
+		assert.Equal(t, good[3:len(good)-2], frame.UserDataRaw)
+		assert.Equal(t, byte(0x7F), frame.EndFlag)
+	}
+
+	// 默认标识的读取器不能识别衍生协议的帧
+	_, err := NewReader(bytes.NewReader(good), types.DefaultLogger).ReadFrame()
+	assert.Error(t, err)
+}
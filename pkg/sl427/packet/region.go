@@ -0,0 +1,64 @@
+// pkg/sl427/packet/region.go
+package packet
+
+import (
+	"strings"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// AdminCode 返回方式1地址中的6位行政区划码
+// 地址为方式2(特征码+站点编码)、行政区划码无效或尚未解析用户数据区时返回false
+func (p *Packet) AdminCode() (string, bool) {
+	if p.UserData == nil {
+		return "", false
+	}
+	addr, ok := p.UserData.Address.(*types.AddressV1)
+	if !ok {
+		return "", false
+	}
+	if _, err := types.BCD.DecodeIntChecked(addr.AdminCode); err != nil {
+		return "", false
+	}
+	return addr.AdminCodeString(), true
+}
+
+// regionRule 行政区划码前缀规则
+type regionRule struct {
+	prefix string // 行政区划码前缀,如"32"表示江苏省
+	tag    string // 区域标签
+}
+
+// RegionTagger 按行政区划码前缀为数据包打区域标签,
+// 用于多租户服务端按区域路由或隔离监测站
+type RegionTagger struct {
+	rules      []regionRule
+	defaultTag string // 无匹配规则或非方式1地址时使用的标签
+}
+
+// NewRegionTagger 创建区域标签器
+func NewRegionTagger(defaultTag string) *RegionTagger {
+	return &RegionTagger{defaultTag: defaultTag}
+}
+
+// Add 添加前缀规则,多个规则匹配时取最长前缀
+func (t *RegionTagger) Add(prefix, tag string) *RegionTagger {
+	t.rules = append(t.rules, regionRule{prefix: prefix, tag: tag})
+	return t
+}
+
+// Tag 返回数据包所属区域的标签
+func (t *RegionTagger) Tag(p *Packet) string {
+	code, ok := p.AdminCode()
+	if !ok {
+		return t.defaultTag
+	}
+
+	tag, matched := t.defaultTag, -1
+	for _, r := range t.rules {
+		if len(r.prefix) > matched && strings.HasPrefix(code, r.prefix) {
+			tag, matched = r.tag, len(r.prefix)
+		}
+	}
+	return tag
+}
@@ -0,0 +1,53 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packetFrom 构建指定地址的上行帧并解析为Packet
+func packetFrom(t *testing.T, addr types.Address) *Packet {
+	t.Helper()
+	frame, err := NewFrameBuilder().
+		Control(types.NewControl(types.DirBit | types.DataTypeRain)).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Build()
+	require.NoError(t, err)
+	return decodeBuilt(t, frame)
+}
+
+func TestRegionTagger(t *testing.T) {
+	beijing, err := types.NewAddressV1([]byte{0x11, 0x01, 0x01}, 1)
+	require.NoError(t, err)
+	nanjing, err := types.NewAddressV1([]byte{0x32, 0x01, 0x02}, 2)
+	require.NoError(t, err)
+	suzhou, err := types.NewAddressV1([]byte{0x32, 0x05, 0x08}, 3)
+	require.NoError(t, err)
+	v2, err := types.NewAddressV2([]byte{0x80, 0x00, 0x00, 0x01})
+	require.NoError(t, err)
+
+	p := packetFrom(t, nanjing)
+	code, ok := p.AdminCode()
+	assert.True(t, ok)
+	assert.Equal(t, "320102", code)
+
+	tagger := NewRegionTagger("other").
+		Add("11", "beijing").
+		Add("32", "jiangsu").
+		Add("3205", "suzhou")
+
+	assert.Equal(t, "beijing", tagger.Tag(packetFrom(t, beijing)))
+	assert.Equal(t, "jiangsu", tagger.Tag(p))
+	assert.Equal(t, "suzhou", tagger.Tag(packetFrom(t, suzhou)))
+	assert.Equal(t, "other", tagger.Tag(packetFrom(t, v2)))
+
+	// 非BCD的行政区划码不参与匹配
+	bad := &Packet{UserData: &types.UserData{Address: &types.AddressV1{AdminCode: []byte{0x3A, 0x01, 0x00}}}}
+	_, ok = bad.AdminCode()
+	assert.False(t, ok)
+	assert.Equal(t, "other", tagger.Tag(bad))
+}
@@ -0,0 +1,38 @@
+// pkg/sl427/packet/upload.go
+package packet
+
+import (
+	"fmt"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+)
+
+// AddressedUpload 携带来源监测站地址的自报数据,便于脱离数据包独立处理
+type AddressedUpload struct {
+	Address types.Address // 监测站地址
+	*types.UploadFrame
+}
+
+// ParseUpload 解析自报实时数据(AFN=C0H)的数据域,并附带监测站地址
+// 数据域本身未携带时间标签时,使用帧的时间标签Tp(自报帧中即为采集时间)
+func (p *Packet) ParseUpload(opts ...types.UploadOption) (*AddressedUpload, error) {
+	if p.UserData == nil {
+		return nil, fmt.Errorf("用户数据区未解析")
+	}
+	if p.UserData.AFN != types.AFNUpload {
+		return nil, fmt.Errorf("不是自报实时数据: %s", p.UserData.AFN)
+	}
+
+	upload, err := types.ParseUploadData(p.UserData.Control.GetType(), p.UserData.DataField, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Timestamp == nil {
+		upload.Timestamp = p.UserData.Tp
+	}
+
+	return &AddressedUpload{
+		Address:     p.UserData.Address,
+		UploadFrame: upload,
+	}, nil
+}
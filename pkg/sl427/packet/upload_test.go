@@ -0,0 +1,44 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacket_ParseUpload(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 7)
+	require.NoError(t, err)
+	frame, err := NewFrameBuilder().
+		Control(types.NewControl(types.DirBit | types.DataTypeWaterLevel)).
+		Address(addr).
+		AFN(types.AFNUpload).
+		Data([]byte{0x34, 0x12, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00}).
+		TimeLabel(types.NewTimestamp(testTime)).
+		Build()
+	require.NoError(t, err)
+
+	upload, err := decodeBuilt(t, frame).ParseUpload()
+	require.NoError(t, err)
+	assert.Equal(t, addr.Bytes(), upload.Address.Bytes())
+	assert.JSONEq(t, `{"SW":51.234}`, string(upload.Items))
+	require.NotNil(t, upload.Timestamp)
+	assert.Equal(t, testTime.Unix(), upload.Timestamp.Seconds())
+}
+
+func TestPacket_ParseUploadNotUpload(t *testing.T) {
+	addr, err := types.NewAddressV1([]byte{0x12, 0x34, 0x56}, 7)
+	require.NoError(t, err)
+	frame, err := NewFrameBuilder().
+		Control(types.NewControl(types.DirBit | types.DataTypeAlarm)).
+		Address(addr).
+		AFN(types.AFNAlarm).
+		Data([]byte{0x00, 0x01, 0x00, 0x00}).
+		Build()
+	require.NoError(t, err)
+
+	_, err = decodeBuilt(t, frame).ParseUpload()
+	assert.Error(t, err)
+}
@@ -106,8 +106,19 @@ func (a *AddressV1) Validate() error {
 	}
 }
 
+// AdminCodeString 将3字节BCD行政区划码解码为6位十进制字符串,如"320100"
+// 行政区划码含非BCD字节(经BCD.DecodeIntChecked校验)时按十六进制原样输出
+func (a *AddressV1) AdminCodeString() string {
+	code, err := BCD.DecodeIntChecked(a.AdminCode)
+	if err != nil {
+		return fmt.Sprintf("%X", a.AdminCode)
+	}
+	return fmt.Sprintf("%0*d", len(a.AdminCode)*2, code)
+}
+
+// GetAddress 返回行政区划码加站点编码的字符串
 func (a *AddressV1) GetAddress() string {
-	return fmt.Sprintf("%s%04d", a.AdminCode, a.StationID)
+	return fmt.Sprintf("%s%04d", a.AdminCodeString(), a.StationID)
 }
 
 // NewAddressV1 创建方式1的地址
@@ -124,6 +135,18 @@ func NewAddressV1(adminCode []byte, stationID uint16) (*AddressV1, error) {
 	return addr, nil
 }
 
+// NewAddressV1WithStationID 由整数站号创建方式1的地址
+// id必须落在监测站(1-60000)、中继站(60001-65534)或广播地址(65535)范围内
+func NewAddressV1WithStationID(adminCode []byte, id uint32) (*AddressV1, error) {
+	if len(adminCode) != AdminCodeLen {
+		return nil, fmt.Errorf("行政区划码长度错误: %d", len(adminCode))
+	}
+	if id == InvalidAddr || id > BroadcastAddr {
+		return nil, fmt.Errorf("站点地址超出范围: %d", id)
+	}
+	return NewAddressV1(adminCode, uint16(id))
+}
+
 // AddressV2 方式2的地址实现(特征码 + 站点编码)
 type AddressV2 struct {
 	StationCode []byte // 4字节HEX格式的站点编码
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAddressV1WithStationID(t *testing.T) {
+	adminCode := []byte{0x32, 0x01, 0x00}
+
+	tests := []struct {
+		name    string
+		id      uint32
+		wantErr bool
+	}{
+		{"station", 1, false},
+		{"max station", MaxStationAddr, false},
+		{"relay", MinRelayAddr, false},
+		{"broadcast", BroadcastAddr, false},
+		{"zero", InvalidAddr, true},
+		{"out of range", BroadcastAddr + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := NewAddressV1WithStationID(adminCode, tt.id)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint16(tt.id), addr.StationID)
+			assert.Equal(t, adminCode, addr.AdminCode)
+		})
+	}
+
+	_, err := NewAddressV1WithStationID([]byte{0x32, 0x01}, 1)
+	assert.Error(t, err)
+}
+
+func TestAddressV1_AdminCodeString(t *testing.T) {
+	tests := []struct {
+		adminCode []byte
+		stationID uint16
+		code      string
+		address   string
+	}{
+		{[]byte{0x32, 0x01, 0x05}, 12, "320105", "3201050012"},
+		{[]byte{0x11, 0x00, 0x00}, MaxStationAddr, "110000", "11000060000"},
+		{[]byte{0x02, 0x01, 0x00}, 1, "020100", "0201000001"},
+	}
+	for _, tt := range tests {
+		addr, err := NewAddressV1(tt.adminCode, tt.stationID)
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, addr.AdminCodeString())
+		assert.Equal(t, tt.address, addr.GetAddress())
+	}
+
+	// 非BCD的行政区划码按十六进制原样输出
+	addr := &AddressV1{AdminCode: []byte{0x3A, 0x01, 0x00}, StationID: 12}
+	assert.Equal(t, "3A0100", addr.AdminCodeString())
+	assert.Equal(t, "3A01000012", addr.GetAddress())
+}
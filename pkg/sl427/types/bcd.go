@@ -2,6 +2,11 @@
 
 package types
 
+import (
+	"fmt"
+	"math"
+)
+
 // BCDCodec BCD编解码器
 type BCDCodec struct{}
 
@@ -75,7 +80,8 @@ func (c BCDCodec) EncodeInt(n uint32, bytes int) []byte {
 	return bcd
 }
 
-// DecodeInt 将BCD解码为整数
+// DecodeInt 将BCD解码为整数(高位在前)
+// 不检查BCD码有效性和溢出,超过4字节(8位十进制)的值可能溢出,需要校验时使用DecodeIntChecked
 func (c BCDCodec) DecodeInt(bcd []byte) uint32 {
 	var n uint32
 	for _, b := range bcd {
@@ -83,3 +89,19 @@ func (c BCDCodec) DecodeInt(bcd []byte) uint32 {
 	}
 	return n
 }
+
+// DecodeIntChecked 将BCD解码为整数(高位在前),并检查BCD码有效性和溢出
+// 4字节及以内总能表示;5字节的值不能超过uint32最大值4294967295
+func (c BCDCodec) DecodeIntChecked(bcd []byte) (uint32, error) {
+	var n uint64
+	for i, b := range bcd {
+		if !c.IsValid(b) {
+			return 0, fmt.Errorf("无效的BCD码: byte[%d]=%02X", i, b)
+		}
+		n = n*100 + uint64(c.FromBCD(b))
+		if n > math.MaxUint32 {
+			return 0, fmt.Errorf("BCD值溢出: % X", bcd)
+		}
+	}
+	return uint32(n), nil
+}
@@ -0,0 +1,24 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBCD_DecodeIntChecked(t *testing.T) {
+	n, err := BCD.DecodeIntChecked([]byte{0x12, 0x34, 0x56, 0x78})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(12345678), n)
+
+	n, err = BCD.DecodeIntChecked([]byte{0x42, 0x94, 0x96, 0x72, 0x95})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4294967295), n)
+
+	_, err = BCD.DecodeIntChecked([]byte{0x99, 0x99, 0x99, 0x99, 0x99})
+	assert.Error(t, err)
+
+	_, err = BCD.DecodeIntChecked([]byte{0x12, 0x3A})
+	assert.Error(t, err)
+}
@@ -51,6 +51,14 @@ func (c *Control) SetDIV(count byte) {
 	c.divs = &count
 }
 
+// DIVS 返回拆分帧计数,非拆分帧返回0
+func (c *Control) DIVS() byte {
+	if c.divs == nil {
+		return 0
+	}
+	return *c.divs
+}
+
 // IsDIV 判断是否为拆分帧
 func (c *Control) IsDIV() bool {
 	return (c.value & 0x40) != 0
@@ -1,6 +1,8 @@
 // pkg/sl427/types/frame.go
 package types
 
+import "bytes"
+
 // 基本帧格式常量
 const (
 	// 帧标识符
@@ -23,6 +25,7 @@ type Frame struct {
 	UserDataRaw []byte // 用户数据区原始字节
 	CS          byte   // 校验码(CRC)
 	EndFlag     byte   // 帧结束标识
+	Unverified  bool   // CS校验未通过但按跳过校验选项解码
 }
 
 // FrameHeader 帧头定义(3字节)
@@ -33,12 +36,29 @@ type Header struct {
 	StartFlag2 byte // 帧起始标识2(68H)
 }
 
-// 计算frame的长度
+// 计算frame的长度,与EncodedLen相同,即len(Raw())
+// 早期版本按用户数据区+7计算,多算了2字节
 func (f *Frame) Len() int {
-	return len(f.UserDataRaw) + 7
+	return f.EncodedLen()
+}
+
+// EncodedLen 返回整帧编码后的字节数: 帧头(3) + 用户数据区 + CS(1) + 结束符(1)
+func (f *Frame) EncodedLen() int {
+	return len(f.UserDataRaw) + 5
 }
 
 // 返回原始数据
 func (f *Frame) Raw() []byte {
 	return append(append([]byte{f.Head.StartFlag1, f.Head.Length, f.Head.StartFlag2}, f.UserDataRaw...), f.CS, f.EndFlag)
 }
+
+// FramesEqual 按字段比较两个帧是否相同(帧头、用户数据区、CS和结束标识)
+func FramesEqual(a, b *Frame) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Head == b.Head &&
+		bytes.Equal(a.UserDataRaw, b.UserDataRaw) &&
+		a.CS == b.CS &&
+		a.EndFlag == b.EndFlag
+}
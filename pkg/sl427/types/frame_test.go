@@ -0,0 +1,48 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFramesEqual(t *testing.T) {
+	newFrame := func() *Frame {
+		return &Frame{
+			Head:        Header{StartFlag1: StartFlag, Length: 3, StartFlag2: StartFlag},
+			UserDataRaw: []byte{0x81, 0x12, 0x34},
+			CS:          0x56,
+			EndFlag:     EndFlag,
+		}
+	}
+
+	a := newFrame()
+	assert.True(t, FramesEqual(a, newFrame()))
+	assert.True(t, FramesEqual(nil, nil))
+	assert.False(t, FramesEqual(a, nil))
+
+	b := newFrame()
+	b.UserDataRaw[2] = 0x35
+	assert.False(t, FramesEqual(a, b))
+
+	b = newFrame()
+	b.CS++
+	assert.False(t, FramesEqual(a, b))
+
+	b = newFrame()
+	b.Head.Length++
+	assert.False(t, FramesEqual(a, b))
+}
+
+func TestFrame_Len(t *testing.T) {
+	f := &Frame{
+		Head:        Header{StartFlag1: StartFlag, Length: 3, StartFlag2: StartFlag},
+		UserDataRaw: []byte{0x81, 0x12, 0x34},
+		CS:          0x56,
+		EndFlag:     EndFlag,
+	}
+	// 帧头(3) + 用户数据区(3) + CS(1) + 结束符(1)
+	assert.Equal(t, 8, f.Len())
+	assert.Equal(t, len(f.Raw()), f.Len())
+	assert.Equal(t, f.EncodedLen(), f.Len())
+}
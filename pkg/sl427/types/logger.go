@@ -6,6 +6,21 @@ type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+// DebugLogger 支持调试级别的日志接口
+// 原始报文字节等调试信息只通过Debugf输出,
+// 仅实现Logger的日志不会收到这些内容,是否输出由实现方的日志级别决定
+type DebugLogger interface {
+	Logger
+	Debugf(format string, v ...interface{})
+}
+
+// Debugf 当l实现DebugLogger时输出调试日志,否则忽略
+func Debugf(l Logger, format string, v ...interface{}) {
+	if d, ok := l.(DebugLogger); ok {
+		d.Debugf(format, v...)
+	}
+}
+
 // 默认的空日志实现
 type noopLogger struct{}
 
@@ -0,0 +1,51 @@
+// pkg/sl427/types/type_code.go
+
+package types
+
+import "fmt"
+
+// TypeCodeInfo 命令与类型码的标准数据项key和名称
+type TypeCodeInfo struct {
+	Key  string // 主数据项的json key,多个参数组合的类型(如气象、水质)为空
+	Name string // 中文名称
+}
+
+// typeCodes 上行自报帧类型码定义
+// 解析函数使用其中的key生成json,使用方也可据此为数据打标签
+var typeCodes = map[byte]TypeCodeInfo{
+	DataTypeRain:       {"YL", "雨量"},
+	DataTypeWaterLevel: {"SW", "水位"},
+	DataTypeFlow:       {"LL", "流量"},
+	DataTypeSpeed:      {"LS", "流速"},
+	DataTypeGate:       {"ZM", "闸位"},
+	DataTypePower:      {"GL", "功率"},
+	DataTypeWeather:    {"", "气象"},
+	DataTypeElectric:   {"DL", "电量"},
+	DataTypeTemp:       {"WT", "水温"},
+	DataTypeQuality:    {"", "水质"},
+	DataTypeSoil:       {"TRHSL", "土壤含水率"},
+	DataTypeEvapor:     {"ZFL", "蒸发量"},
+	DataTypeAlarm:      {"", "报警状态"},
+	DataTypeRainStat:   {"", "统计雨量"},
+	DataTypePressure:   {"SY", "水压"},
+}
+
+// LookupTypeCode 查找类型码的定义
+func LookupTypeCode(code byte) (TypeCodeInfo, bool) {
+	info, ok := typeCodes[code&CodeMask]
+	return info, ok
+}
+
+// TypeCodeName 返回类型码的中文名称,未定义的类型码返回"未知类型(0xNN)"
+func TypeCodeName(code byte) string {
+	if info, ok := LookupTypeCode(code); ok {
+		return info.Name
+	}
+	return fmt.Sprintf("未知类型(0x%02X)", code)
+}
+
+// TypeCodeKey 返回类型码主数据项的json key,组合类型或未定义的类型码返回空字符串
+func TypeCodeKey(code byte) string {
+	info, _ := LookupTypeCode(code)
+	return info.Key
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeCodeName(t *testing.T) {
+	tests := []struct {
+		code byte
+		key  string
+		name string
+	}{
+		{DataTypeRain, "YL", "雨量"},
+		{DataTypeWaterLevel, "SW", "水位"},
+		{DataTypeFlow, "LL", "流量"},
+		{DataTypeQuality, "", "水质"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.name, TypeCodeName(tt.code))
+		assert.Equal(t, tt.key, TypeCodeKey(tt.code))
+	}
+
+	// 带方向位的控制域同样可以查找
+	assert.Equal(t, "雨量", TypeCodeName(DirBit|DataTypeRain))
+
+	_, ok := LookupTypeCode(CmdUpConfirm)
+	assert.False(t, ok)
+	assert.Equal(t, "未知类型(0x00)", TypeCodeName(CmdUpConfirm))
+	assert.Equal(t, "", TypeCodeKey(CmdUpConfirm))
+}
@@ -4,14 +4,31 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 )
 
 // 注册函数
 var parseUploadFuncMap = map[byte]func(byte, []byte) (json.RawMessage, error){
 	DataTypeRain:       parseRain,
 	DataTypeWaterLevel: parseWaterLevel,
+	DataTypeRainStat:   parseRainStat,
+	DataTypeSoil:       parseSoil,
+	DataTypeEvapor:     parseEvapor,
+	DataTypeQuality:    parseQuality,
+	DataTypeAlarm:      parseAlarm,
+	DataTypeFlow:       parseFlow,
+	DataTypePressure:   parsePressure,
+	DataTypePower:      parsePower,
+	DataTypeElectric:   parseElectric,
+	DataTypeWeather:    parseWeather,
+	DataTypeSpeed:      parseSpeed,
+	DataTypeGate:       parseGate,
 }
 
+// DeviceStatusLen 设备状态长度,规约规定自报数据域最后4字节为报警状态和终端机状态
+const DeviceStatusLen = 4
+
 // DeviceMode 确认帧的数据域,终端机工作模式
 const (
 	ModeCompatible = 0x00 // 兼容工作状态
@@ -28,42 +45,126 @@ type DeviceStatus struct {
 
 // UploadFrame 自报数据帧
 type UploadFrame struct {
-	RawData []byte          // 原始数据
-	Items   json.RawMessage // 数据项
-	Status  DeviceStatus    // 状态信息
+	RawData   []byte          // 原始数据
+	Items     json.RawMessage // 数据项
+	Status    *DeviceStatus   // 状态信息(数据域末尾4字节,原样输出时为nil)
+	Timestamp *TimeLabel      // 数据域末尾携带的时间标签(采集时间,可选)
+}
+
+// uploadOptions 自报数据解析选项
+type uploadOptions struct {
+	rawFallback bool // 未注册的类型码输出原始数据而不是报错
+}
+
+// UploadOption 自报数据解析选项
+type UploadOption func(*uploadOptions)
+
+// WithRawFallback 对未注册解析函数的类型码不再报错,
+// 而是输出包含类型码和数据域十六进制原文的json: {"type":9,"raw":"0102"}
+// 便于现场采集厂商自定义类型的数据
+func WithRawFallback() UploadOption {
+	return func(o *uploadOptions) {
+		o.rawFallback = true
+	}
 }
 
 // ParseUploadData 解析自报数据的数据域D
 // dataType 控制域C中的命令与类型码
 // dataField 数据域D的原始字节流
-// dataMap 数据项映射表:[命令与类型码]json的key
-func ParseUploadData(dataType byte, dataField []byte) (*UploadFrame, error) {
+// opts 可选的解析选项
+func ParseUploadData(dataType byte, dataField []byte, opts ...UploadOption) (*UploadFrame, error) {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// 获取解析函数
 	parseFunc, ok := parseUploadFuncMap[dataType]
 	if !ok {
-		return nil, fmt.Errorf("未找到解析函数，不支持的类型码: %d", dataType)
+		if !o.rawFallback {
+			return nil, fmt.Errorf("未找到解析函数，不支持的类型码: %d", dataType)
+		}
+		parseFunc = parseRaw
 	}
 
 	// 解析数据
-	items, err := parseFunc(dataType, dataField)
+	// 数据域末尾形如时间标签时先剥离后解析,剥离后解析失败则视为普通数据整体解析;
+	// 原样输出时无法判断剥离是否正确,不做剥离以免吞掉数据
+	var timestamp *TimeLabel
+	var items json.RawMessage
+	var status *DeviceStatus
+	var err error
+	if n := len(dataField); ok && n > TimestampLen && isValidTimeLabel(dataField[n-TimestampLen:]) {
+		items, status, err = parseUploadBody(parseFunc, ok, dataType, dataField[:n-TimestampLen])
+		if err == nil {
+			timestamp, err = ParseTimestamp(dataField[n-TimestampLen:])
+		}
+	}
+	if timestamp == nil {
+		items, status, err = parseUploadBody(parseFunc, ok, dataType, dataField)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// 解析状态信息
-	status := DeviceStatus{
-		Alarm: uint16(dataField[0])<<8 | uint16(dataField[1]),
-		State: uint16(dataField[2])<<8 | uint16(dataField[3]),
+	// 校验数据项(原样输出的数据不做校验)
+	if ok {
+		if err := validateItems(dataType, items); err != nil {
+			return nil, err
+		}
 	}
 
 	// 创建自报数据帧
 	return &UploadFrame{
-		RawData: dataField,
-		Items:   items,
-		Status:  status,
+		RawData:   dataField,
+		Items:     items,
+		Status:    status,
+		Timestamp: timestamp,
+	}, nil
+}
+
+// parseUploadBody 解析去掉时间标签后的数据,
+// 先从末尾取出4字节设备状态,其余数据交给解析函数;withStatus为false(原样输出)时不拆分
+func parseUploadBody(parseFunc func(byte, []byte) (json.RawMessage, error), withStatus bool, dataType byte, data []byte) (json.RawMessage, *DeviceStatus, error) {
+	if !withStatus {
+		items, err := parseFunc(dataType, data)
+		return items, nil, err
+	}
+
+	n := len(data)
+	if n < DeviceStatusLen {
+		return nil, nil, fmt.Errorf("数据域长度%d不足以包含%d字节设备状态(规约规定自报数据域末尾为报警状态和终端机状态)", n, DeviceStatusLen)
+	}
+	status := data[n-DeviceStatusLen:]
+	items, err := parseFunc(dataType, data[:n-DeviceStatusLen])
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, &DeviceStatus{
+		Alarm: uint16(status[0])<<8 | uint16(status[1]),
+		State: uint16(status[2])<<8 | uint16(status[3]),
 	}, nil
 }
 
+// parseAlarm 解析报警状态数据,状态本身由ParseUploadData取出,数据项为空
+func parseAlarm(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) != 0 {
+		return nil, fmt.Errorf("invalid alarm data length: %d", len(data))
+	}
+	return json.RawMessage("{}"), nil
+}
+
+// parseRaw 原样输出类型码和数据域的十六进制表示
+func parseRaw(dataType byte, data []byte) (json.RawMessage, error) {
+	return json.Marshal(struct {
+		Type byte   `json:"type"`
+		Raw  string `json:"raw"`
+	}{
+		Type: dataType,
+		Raw:  fmt.Sprintf("%X", data),
+	})
+}
+
 // ParseRain 解析雨量数据(3字节BCD码)
 func parseRain(dataType byte, data []byte) (json.RawMessage, error) {
 	if len(data) != 3 {
@@ -74,9 +175,11 @@ func parseRain(dataType byte, data []byte) (json.RawMessage, error) {
 	value := BCD.DecodeInt(data)
 
 	// 转换为json格式
-	return json.Marshal(map[string]interface{}{
-		"YL": float64(value) / 10.0, // 保留一位小数
-	})
+	buf := make([]byte, 0, 32)
+	buf = append(buf, '{')
+	buf = appendSeqField(buf, TypeCodeKey(dataType), 0, float64(value)/10.0) // 保留一位小数
+	buf = append(buf, '}')
+	return buf, nil
 }
 
 // ParseWaterLevel 解析水位数据(每个水位4字节BCD码)
@@ -85,12 +188,13 @@ func parseWaterLevel(dataType byte, data []byte) (json.RawMessage, error) {
 		return nil, fmt.Errorf("invalid water level data length: %d", len(data))
 	}
 
-	// 构造结果map
-	result := make(map[string]interface{})
-
 	// 计算水位个数
 	count := len(data) / 4
 
+	// 按水位个数预分配结果缓冲区
+	buf := make([]byte, 0, 2+count*24)
+	buf = append(buf, '{')
+
 	// 解析每个水位
 	for i := 0; i < count; i++ {
 		// 获取当前水位数据
@@ -115,13 +219,391 @@ func parseWaterLevel(dataType byte, data []byte) (json.RawMessage, error) {
 		}
 
 		// 生成key(第一个用SW,后续用SW2,SW3...)
-		key := "SW"
-		if i > 0 {
-			key = fmt.Sprintf("SW%d", i+1)
+		buf = appendSeqField(buf, TypeCodeKey(dataType), i, value)
+	}
+
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// 统计雨量类型(雨量类型字节D7~D6)
+const (
+	RainStatPeriod = 0x00 // 时段降雨量,时段长度步长5min
+	RainStatHour   = 0x01 // 小时降雨量,时段长度步长1h
+	RainStatDay    = 0x02 // 日降雨量,时段长度步长1d
+	RainStatTotal  = 0x03 // 测试数据,降雨量为累计雨量
+)
+
+// rainStatKeys 各统计雨量类型的json key
+var rainStatKeys = [...]string{
+	RainStatPeriod: "SDYL", // 时段雨量
+	RainStatHour:   "XSYL", // 小时雨量
+	RainStatDay:    "RYL",  // 日雨量
+	RainStatTotal:  "LJYL", // 累计雨量
+}
+
+// rainStatStep 各统计雨量类型时段长度的步长(分钟)
+var rainStatStep = [...]int{
+	RainStatPeriod: 5,
+	RainStatHour:   60,
+	RainStatDay:    24 * 60,
+}
+
+// parseRainStat 解析统计雨量数据(规约表54)
+// 每组4字节: 1字节雨量类型(D7~D6类型,D5~D0时段长度) + 3字节BCD雨量(同自报雨量,单位0.1mm)
+// 可包含多组,同类型的第二组起key依次加序号(如XSYL2)。
+// 除累计雨量外,每组另输出时段长度(分钟),key为雨量key加"SC"(如XSYLSC)
+func parseRainStat(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) < 4 || len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid rain stat data length: %d", len(data))
+	}
+
+	var seqs [len(rainStatKeys)]int
+	buf := make([]byte, 0, 2+len(data)/4*32)
+	buf = append(buf, '{')
+	for offset := 0; offset < len(data); offset += 4 {
+		kind := data[offset] >> 6
+		period := int(data[offset] & 0x3F)
+		value := float64(BCD.DecodeInt(data[offset+1:offset+4])) / 10.0
+
+		key := rainStatKeys[kind]
+		buf = appendSeqField(buf, key, seqs[kind], value)
+		if kind != RainStatTotal {
+			buf = appendSeqField(buf, key+"SC", seqs[kind], float64(period*rainStatStep[kind]))
+		}
+		seqs[kind]++
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// parseSoil 解析土壤含水率数据(规约表42,每个仪表4字节BCD码)
+// 前2字节为含水率(0~99.9),后2字节为采集点深度(0~999cm)
+// 含水率key为TRHSL,深度key为TRSD,多个仪表依次为TRHSL2/TRSD2...
+func parseSoil(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) < 4 || len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid soil moisture data length: %d", len(data))
+	}
+
+	count := len(data) / 4
+	buf := make([]byte, 0, 2+count*32)
+	buf = append(buf, '{')
+	for i := 0; i < count; i++ {
+		d := data[i*4 : i*4+4]
+		// BYTE1: 个位|十分位, BYTE2: -|十位 (单位0.1)
+		moisture := int(d[1]&0x0F)*100 + int(d[0]>>4)*10 + int(d[0]&0x0F)
+		// BYTE3: 十位|个位, BYTE4: -|百位 (单位cm)
+		depth := int(d[3]&0x0F)*100 + int(d[2]>>4)*10 + int(d[2]&0x0F)
+
+		buf = appendSeqField(buf, TypeCodeKey(dataType), i, float64(moisture)/10.0)
+		buf = appendSeqField(buf, "TRSD", i, float64(depth))
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// parseEvapor 解析蒸发量数据(规约表43,每个仪器3字节BCD码,0~9999.9mm)
+// key为ZFL,多个仪器依次为ZFL2,ZFL3...
+func parseEvapor(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) < 3 || len(data)%3 != 0 {
+		return nil, fmt.Errorf("invalid evaporation data length: %d", len(data))
+	}
+
+	count := len(data) / 3
+	buf := make([]byte, 0, 2+count*16)
+	buf = append(buf, '{')
+	for i := 0; i < count; i++ {
+		d := data[i*3 : i*3+3]
+		// BYTE1: 个位|十分位, BYTE2: 百位|十位, BYTE3: -|千位 (单位0.1mm)
+		tenths := int(d[2]&0x0F)*10000 + int(d[1]>>4)*1000 + int(d[1]&0x0F)*100 +
+			int(d[0]>>4)*10 + int(d[0]&0x0F)
+
+		buf = appendSeqField(buf, TypeCodeKey(dataType), i, float64(tenths)/10.0)
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// flowFieldLen 流量数据中瞬时流量和累计水量各自的长度
+const flowFieldLen = 5
+
+// 瞬时流量单位(规约表35 BYTE5 D3~D0)
+const (
+	FlowUnitPerSecond = 0x00 // m³/s
+	FlowUnitPerHour   = 0x03 // m³/h
+)
+
+// parseFlow 解析流量(水量)数据,每个仪表10字节
+// 前5字节为瞬时流量(规约表35): BYTE1~BYTE4为BCD码低位在前,3位小数;
+// BYTE5高4位为符号位(0为正,非0为负),低4位为单位(00B为m³/s,11B为m³/h),
+// 以m³/h上报的流量统一换算为m³/s输出。
+// 后5字节为累计水量(规约表36): BCD码低位在前,BYTE5只有低4位(亿位)为数值位,单位m³。
+// 某一项全部为AAH表示仪表未提供该项,输出时省略;只有5字节时视为仅含瞬时流量。
+// 瞬时流量key为LL,累计水量key为LLZ,多个仪表依次为LL2/LLZ2...
+func parseFlow(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) == flowFieldLen {
+		data = append(append([]byte{}, data...), 0xAA, 0xAA, 0xAA, 0xAA, 0xAA)
+	}
+	if len(data) < flowFieldLen*2 || len(data)%(flowFieldLen*2) != 0 {
+		return nil, fmt.Errorf("invalid flow data length: %d", len(data))
+	}
+
+	count := len(data) / (flowFieldLen * 2)
+	buf := make([]byte, 0, 2+count*40)
+	buf = append(buf, '{')
+	for i := 0; i < count; i++ {
+		d := data[i*flowFieldLen*2 : (i+1)*flowFieldLen*2]
+		flow, total := d[:flowFieldLen], d[flowFieldLen:]
+
+		if !isAbsentField(flow) {
+			flag := flow[flowFieldLen-1]
+			value := float64(decodeBCDLowFirst(flow[:flowFieldLen-1])) / 1000.0
+			switch flag & 0x0F {
+			case FlowUnitPerSecond:
+			case FlowUnitPerHour:
+				value /= 3600
+			default:
+				return nil, fmt.Errorf("invalid flow unit: %X", flag&0x0F)
+			}
+			if flag>>4 != 0 {
+				value = -value
+			}
+			buf = appendSeqField(buf, TypeCodeKey(dataType), i, value)
+		}
+		if !isAbsentField(total) {
+			// 最高字节只有低4位为数值位
+			value := uint64(total[flowFieldLen-1]&0x0F)*1e8 + decodeBCDLowFirst(total[:flowFieldLen-1])
+			buf = appendSeqField(buf, "LLZ", i, float64(value))
+		}
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// isAbsentField 判断字段是否全部为AAH(仪表未提供该项)
+func isAbsentField(d []byte) bool {
+	for _, b := range d {
+		if b != 0xAA {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeBCDLowFirst 解码低位在前的BCD码
+func decodeBCDLowFirst(d []byte) uint64 {
+	var n uint64
+	for j := len(d) - 1; j >= 0; j-- {
+		n = n*100 + uint64(BCD.FromBCD(d[j]))
+	}
+	return n
+}
+
+// parsePressure 解析水压数据,每个测点4字节BCD码,低位在前,单位0.01kPa(0~999999.99kPa)
+// key为SY,多个测点依次为SY2,SY3...
+func parsePressure(dataType byte, data []byte) (json.RawMessage, error) {
+	return parseScaledBCD("pressure", TypeCodeKey(dataType), 4, 2, data)
+}
+
+// parsePower 解析功率数据,每个仪表3字节BCD码,低位在前,单位kW(0~999999kW)
+// key为GL,多个仪表依次为GL2,GL3...
+func parsePower(dataType byte, data []byte) (json.RawMessage, error) {
+	return parseScaledBCD("power", TypeCodeKey(dataType), 3, 0, data)
+}
+
+// parseElectric 解析电量数据,每个电表4字节BCD码,低位在前,单位0.01kWh(0~999999.99kWh)
+// key为DL,多个电表依次为DL2,DL3...
+func parseElectric(dataType byte, data []byte) (json.RawMessage, error) {
+	return parseScaledBCD("electric", TypeCodeKey(dataType), 4, 2, data)
+}
+
+// parseSpeed 解析流速数据(规约表37),每个测点3字节BCD码,低位在前,单位0.001m/s(-99.999~+99.999m/s)
+// BYTE3高4位为符号位,FH表示负值,低4位为十位
+// key为LS,多个测点依次为LS2,LS3...
+func parseSpeed(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) < 3 || len(data)%3 != 0 {
+		return nil, fmt.Errorf("invalid speed data length: %d", len(data))
+	}
+
+	count := len(data) / 3
+	buf := make([]byte, 0, 2+count*16)
+	buf = append(buf, '{')
+	for i := 0; i < count; i++ {
+		d := data[i*3 : i*3+3]
+		// 最高字节只有低4位为数值位
+		digits := uint64(d[2]&0x0F)*10000 + decodeBCDLowFirst(d[:2])
+		value := float64(digits) / 1000.0
+		if d[2]>>4 == 0x0F {
+			value = -value
+		}
+		buf = appendSeqField(buf, TypeCodeKey(dataType), i, value)
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// parseGate 解析闸位数据,每孔闸门3字节BCD码,低位在前,单位0.01m(0~999.99m)
+// key为ZM,多孔闸门依次为ZM2,ZM3...
+func parseGate(dataType byte, data []byte) (json.RawMessage, error) {
+	return parseScaledBCD("gate position", TypeCodeKey(dataType), 3, 2, data)
+}
+
+// parseScaledBCD 解析由多个等长BCD值(低位在前)组成的数据
+// width为每个值的字节数,decimals为小数位数,name用于错误信息
+func parseScaledBCD(name, key string, width, decimals int, data []byte) (json.RawMessage, error) {
+	if len(data) < width || len(data)%width != 0 {
+		return nil, fmt.Errorf("invalid %s data length: %d", name, len(data))
+	}
+
+	count := len(data) / width
+	buf := make([]byte, 0, 2+count*24)
+	buf = append(buf, '{')
+	for i := 0; i < count; i++ {
+		raw := decodeBCDLowFirst(data[i*width : (i+1)*width])
+		buf = appendSeqField(buf, key, i, float64(raw)/math.Pow10(decimals))
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// weatherDataLen 气象数据长度: 气压3字节 + 风速(含风向)3字节 + 气温2字节
+const weatherDataLen = 8
+
+// parseWeather 解析气象数据(规约表40),固定8字节:
+// BYTE1~BYTE3为气压,单位10²Pa(0~99999),key为QY;
+// BYTE4~BYTE6为风速,单位0.01m/s(0~999.99),key为FS,BYTE6高4位为风向(0~8),key为FX;
+// BYTE7~BYTE8为气温,单位0.1℃(-99.9~99.9),BYTE8高4位为符号位,FH表示负值,key为QW。
+// 不采集或无效的要素各数值位全为AH,输出时省略
+func parseWeather(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) != weatherDataLen {
+		return nil, fmt.Errorf("invalid weather data length: %d", len(data))
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '{')
+
+	// 气压 BYTE1: 十位|个位, BYTE2: 千位|百位, BYTE3: -|万位
+	if !isAbsentNibbles(data[0]>>4, data[0]&0x0F, data[1]>>4, data[1]&0x0F, data[2]&0x0F) {
+		value := uint64(data[2]&0x0F)*10000 + decodeBCDLowFirst(data[0:2])
+		buf = appendSeqField(buf, "QY", 0, float64(value))
+	}
+
+	// 风速 BYTE4: 十分位|百分位, BYTE5: 个位|十位, BYTE6: 风向|百位
+	if !isAbsentNibbles(data[3]>>4, data[3]&0x0F, data[4]>>4, data[4]&0x0F, data[5]&0x0F) {
+		hundredths := int(data[5]&0x0F)*10000 + int(data[4]&0x0F)*1000 + int(data[4]>>4)*100 +
+			int(data[3]>>4)*10 + int(data[3]&0x0F)
+		buf = appendSeqField(buf, "FS", 0, float64(hundredths)/100.0)
+	}
+	if direction := data[5] >> 4; !isAbsentNibbles(direction) {
+		if direction > 8 {
+			return nil, fmt.Errorf("invalid wind direction: %d", direction)
+		}
+		buf = appendSeqField(buf, "FX", 0, float64(direction))
+	}
+
+	// 气温 BYTE7: 个位|十分位, BYTE8: 符号位|十位
+	if !isAbsentNibbles(data[6]>>4, data[6]&0x0F, data[7]&0x0F) {
+		tenths := int(data[7]&0x0F)*100 + int(data[6]>>4)*10 + int(data[6]&0x0F)
+		value := float64(tenths) / 10.0
+		if data[7]>>4 == 0x0F {
+			value = -value
+		}
+		buf = appendSeqField(buf, "QW", 0, value)
+	}
+
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// isAbsentNibbles 判断数值位是否全部为AH(不采集或数据无效)
+func isAbsentNibbles(nibbles ...byte) bool {
+	for _, n := range nibbles {
+		if n != 0x0A {
+			return false
+		}
+	}
+	return true
+}
+
+// qualityParam 水质参数定义
+type qualityParam struct {
+	Key      string // json key
+	Decimals int    // 小数位数,原始整数值除以10^Decimals得到实际值
+}
+
+// qualityParams 水质参数种类位定义(位序号 -> 参数)
+// 规约只规定了"5字节种类位图 + 每种参数4字节BCD"的格式,未在本文档中给出位定义,
+// 此处按水资源监测常用的水质参数顺序约定,未定义的位输出为SZ<位序号>且不含小数
+var qualityParams = map[int]qualityParam{
+	0:  {"WT", 1},    // 水温(℃)
+	1:  {"PH", 2},    // pH值
+	2:  {"DO", 2},    // 溶解氧(mg/L)
+	3:  {"CODMN", 2}, // 高锰酸盐指数(mg/L)
+	4:  {"COND", 0},  // 电导率(μS/cm)
+	5:  {"ORP", 1},   // 氧化还原电位(mV)
+	6:  {"TURB", 1},  // 浊度(NTU)
+	7:  {"COD", 2},   // 化学需氧量(mg/L)
+	8:  {"BOD5", 2},  // 五日生化需氧量(mg/L)
+	9:  {"NH3N", 2},  // 氨氮(mg/L)
+	10: {"TN", 2},    // 总氮(mg/L)
+	11: {"TP", 3},    // 总磷(mg/L)
+}
+
+// qualityBitmapLen 水质参数种类位图长度
+const qualityBitmapLen = 5
+
+// parseQuality 解析水质数据
+// 前5字节为参数种类位图(BIN,低字节在前,每字节D0起),置1的参数按位序依次给出实测值,
+// 每个实测值为4字节BCD码(0~99999999),低位在前,高位在后
+func parseQuality(dataType byte, data []byte) (json.RawMessage, error) {
+	if len(data) < qualityBitmapLen {
+		return nil, fmt.Errorf("invalid water quality data length: %d", len(data))
+	}
+
+	// 统计位图中的参数个数并校验长度
+	var bits []int
+	for bit := 0; bit < qualityBitmapLen*8; bit++ {
+		if data[bit/8]&(1<<(bit%8)) != 0 {
+			bits = append(bits, bit)
+		}
+	}
+	if len(data) != qualityBitmapLen+len(bits)*4 {
+		return nil, fmt.Errorf("invalid water quality data length: %d, want %d for %d params",
+			len(data), qualityBitmapLen+len(bits)*4, len(bits))
+	}
+
+	buf := make([]byte, 0, 2+len(bits)*24)
+	buf = append(buf, '{')
+	for i, bit := range bits {
+		v := data[qualityBitmapLen+i*4 : qualityBitmapLen+i*4+4]
+		// 低位在前: 由高字节向低字节累加
+		var raw uint32
+		for j := len(v) - 1; j >= 0; j-- {
+			raw = raw*100 + uint32(BCD.FromBCD(v[j]))
 		}
 
-		result[key] = value
+		param, ok := qualityParams[bit]
+		if !ok {
+			param = qualityParam{Key: "SZ" + strconv.Itoa(bit)}
+		}
+		buf = appendSeqField(buf, param.Key, 0, float64(raw)/math.Pow10(param.Decimals))
 	}
+	buf = append(buf, '}')
+	return buf, nil
+}
 
-	return json.Marshal(result)
+// appendSeqField 向JSON对象缓冲区追加一个数值字段
+// seq为同类数据的序号(从0开始),第一个直接使用key,后续依次为key2,key3...
+// 直接拼接字节避免map和interface{}装箱带来的内存分配
+func appendSeqField(buf []byte, key string, seq int, value float64) []byte {
+	if len(buf) > 1 {
+		buf = append(buf, ',')
+	}
+	buf = append(buf, '"')
+	buf = append(buf, key...)
+	if seq > 0 {
+		buf = strconv.AppendInt(buf, int64(seq+1), 10)
+	}
+	buf = append(buf, '"', ':')
+	return strconv.AppendFloat(buf, value, 'f', -1, 64)
 }
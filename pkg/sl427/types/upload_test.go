@@ -0,0 +1,476 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 多水位测试数据: 3个水位值 + 4字节设备状态
+var multiWaterLevelData = []byte{
+	0x34, 0x12, 0x05, 0x00, // 51.234m
+	0x78, 0x56, 0x01, 0xF0, // -15.678m
+	0x00, 0x01, 0x00, 0x00, // 0.1m
+	0x11, 0x11, 0x00, 0x00, // 报警状态1111H,终端机状态0000H
+}
+
+func TestParseUploadData_WaterLevel(t *testing.T) {
+	frame, err := ParseUploadData(DataTypeWaterLevel, multiWaterLevelData)
+	require.NoError(t, err)
+
+	var items map[string]float64
+	require.NoError(t, json.Unmarshal(frame.Items, &items))
+	assert.Len(t, items, 3)
+	assert.InDelta(t, 51.234, items["SW"], 1e-9)
+	assert.InDelta(t, -15.678, items["SW2"], 1e-9)
+	assert.InDelta(t, 0.1, items["SW3"], 1e-9)
+
+	require.NotNil(t, frame.Status)
+	assert.Equal(t, DeviceStatus{Alarm: 0x1111, State: 0x0000}, *frame.Status)
+}
+
+func BenchmarkParseUploadData(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseUploadData(DataTypeWaterLevel, multiWaterLevelData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// withStatus 在数据项后追加4字节全0的设备状态,组成完整的自报数据域
+func withStatus(data ...byte) []byte {
+	return append(append([]byte{}, data...), 0x00, 0x00, 0x00, 0x00)
+}
+
+// parseItems 解析自报数据(末尾补设备状态)并返回数据项
+func parseItems(t *testing.T, dataType byte, data []byte) map[string]float64 {
+	t.Helper()
+	frame, err := ParseUploadData(dataType, withStatus(data...))
+	require.NoError(t, err)
+
+	var items map[string]float64
+	require.NoError(t, json.Unmarshal(frame.Items, &items))
+	return items
+}
+
+func TestParseUploadData_RainStat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]float64
+	}{
+		{
+			name: "period rain of 15 minutes",
+			data: []byte{0x03, 0x00, 0x01, 0x25},
+			want: map[string]float64{"SDYL": 12.5, "SDYLSC": 15},
+		},
+		{
+			name: "hourly rain",
+			data: []byte{0x41, 0x00, 0x00, 0x36},
+			want: map[string]float64{"XSYL": 3.6, "XSYLSC": 60},
+		},
+		{
+			name: "daily and accumulated rain",
+			data: []byte{0x81, 0x00, 0x10, 0x00, 0xC0, 0x01, 0x23, 0x45},
+			want: map[string]float64{"RYL": 100, "RYLSC": 1440, "LJYL": 1234.5},
+		},
+		{
+			name: "two hourly groups",
+			data: []byte{0x41, 0x00, 0x00, 0x10, 0x43, 0x00, 0x00, 0x30},
+			want: map[string]float64{"XSYL": 1, "XSYLSC": 60, "XSYL2": 3, "XSYLSC2": 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseItems(t, DataTypeRainStat, tt.data))
+		})
+	}
+
+	_, err := ParseUploadData(DataTypeRainStat, withStatus(0x41, 0x00, 0x00, 0x10, 0x43))
+	assert.Error(t, err)
+}
+
+func TestParseUploadData_SoilAndEvapor(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType byte
+		data     []byte
+		want     map[string]float64
+	}{
+		{
+			name:     "soil single depth",
+			dataType: DataTypeSoil,
+			data:     []byte{0x56, 0x02, 0x20, 0x00}, // 25.6 @ 20cm
+			want:     map[string]float64{"TRHSL": 25.6, "TRSD": 20},
+		},
+		{
+			name:     "soil two depths",
+			dataType: DataTypeSoil,
+			data:     []byte{0x56, 0x02, 0x20, 0x00, 0x89, 0x03, 0x50, 0x01}, // 25.6 @ 20cm, 38.9 @ 150cm
+			want:     map[string]float64{"TRHSL": 25.6, "TRSD": 20, "TRHSL2": 38.9, "TRSD2": 150},
+		},
+		{
+			name:     "evaporation",
+			dataType: DataTypeEvapor,
+			data:     []byte{0x45, 0x23, 0x01}, // 1234.5mm
+			want:     map[string]float64{"ZFL": 1234.5},
+		},
+		{
+			name:     "two evaporation pans",
+			dataType: DataTypeEvapor,
+			data:     []byte{0x45, 0x23, 0x01, 0x30, 0x00, 0x00}, // 1234.5mm, 3.0mm
+			want:     map[string]float64{"ZFL": 1234.5, "ZFL2": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseItems(t, tt.dataType, tt.data))
+		})
+	}
+}
+
+func TestParseUploadData_Quality(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]float64
+	}{
+		{
+			name: "two params",
+			data: []byte{
+				0x06, 0x00, 0x00, 0x00, 0x00, // PH + DO
+				0x25, 0x07, 0x00, 0x00, // PH 7.25
+				0x50, 0x08, 0x00, 0x00, // DO 8.50
+			},
+			want: map[string]float64{"PH": 7.25, "DO": 8.5},
+		},
+		{
+			name: "four params with an undefined bit",
+			data: []byte{
+				0x51, 0x00, 0x00, 0x00, 0x01, // WT + COND + TURB + bit32
+				0x85, 0x01, 0x00, 0x00, // WT 18.5
+				0x50, 0x23, 0x01, 0x00, // COND 12350
+				0x32, 0x00, 0x00, 0x00, // TURB 3.2
+				0x78, 0x56, 0x34, 0x12, // SZ32 12345678
+			},
+			want: map[string]float64{"WT": 18.5, "COND": 12350, "TURB": 3.2, "SZ32": 12345678},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseItems(t, DataTypeQuality, tt.data))
+		})
+	}
+
+	t.Run("length mismatch", func(t *testing.T) {
+		_, err := ParseUploadData(DataTypeQuality, withStatus(0x06, 0x00, 0x00, 0x00, 0x00, 0x25, 0x07, 0x00, 0x00))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseUploadData_RawFallback(t *testing.T) {
+	data := []byte{0x12, 0x34, 0xAB, 0xCD}
+
+	_, err := ParseUploadData(DataTypeTemp, data)
+	assert.Error(t, err, "unregistered type must fail without the fallback")
+
+	frame, err := ParseUploadData(DataTypeTemp, data, WithRawFallback())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":9,"raw":"1234ABCD"}`, string(frame.Items))
+
+	// 形如时间标签的末尾也原样保留,不剥离
+	tail := NewTimestamp(time.Date(2024, 11, 10, 8, 0, 0, 0, time.Local)).Bytes()
+	frame, err = ParseUploadData(DataTypeTemp, append(append([]byte{}, data...), tail...), WithRawFallback())
+	require.NoError(t, err)
+	assert.Nil(t, frame.Timestamp)
+	assert.JSONEq(t, fmt.Sprintf(`{"type":9,"raw":"1234ABCD%X"}`, tail), string(frame.Items))
+
+	// 已注册的类型不受影响
+	frame, err = ParseUploadData(DataTypeEvapor, withStatus(0x45, 0x23, 0x01), WithRawFallback())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ZFL":1234.5}`, string(frame.Items))
+}
+
+func TestParseUploadData_Timestamp(t *testing.T) {
+	collected := time.Date(2024, 11, 10, 8, 0, 0, 0, time.Local)
+	level := withStatus(0x34, 0x12, 0x05, 0x00) // 51.234m
+
+	t.Run("with time label", func(t *testing.T) {
+		data := append(append([]byte{}, level...), NewTimestamp(collected).Bytes()...)
+		frame, err := ParseUploadData(DataTypeWaterLevel, data)
+		require.NoError(t, err)
+		require.NotNil(t, frame.Timestamp)
+		assert.Equal(t, collected.Unix(), frame.Timestamp.Seconds())
+		assert.JSONEq(t, `{"SW":51.234}`, string(frame.Items))
+		assert.Equal(t, data, frame.RawData)
+	})
+
+	t.Run("without time label", func(t *testing.T) {
+		frame, err := ParseUploadData(DataTypeWaterLevel, level)
+		require.NoError(t, err)
+		assert.Nil(t, frame.Timestamp)
+		assert.JSONEq(t, `{"SW":51.234}`, string(frame.Items))
+	})
+
+	t.Run("time-label-like tail that is really data", func(t *testing.T) {
+		// 一组土壤含水率加设备状态,后7字节恰好是合法时间标签(01-01 00:00:00),
+		// 剥离后只剩1字节无法解析,应整体按数据解析
+		data := []byte{0x56, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00}
+		frame, err := ParseUploadData(DataTypeSoil, data)
+		require.NoError(t, err)
+		assert.Nil(t, frame.Timestamp)
+		assert.JSONEq(t, `{"TRHSL":5.6,"TRSD":0}`, string(frame.Items))
+		require.NotNil(t, frame.Status)
+		assert.Equal(t, uint16(0x0101), frame.Status.Alarm)
+	})
+}
+
+func TestParseUploadData_Status(t *testing.T) {
+	t.Run("rain carries status", func(t *testing.T) {
+		frame, err := ParseUploadData(DataTypeRain, []byte{0x00, 0x12, 0x34, 0x00, 0x05, 0x00, 0x01})
+		require.NoError(t, err)
+		require.NotNil(t, frame.Status)
+		assert.Equal(t, uint16(0x0005), frame.Status.Alarm)
+		assert.Equal(t, uint16(0x0001), frame.Status.State)
+		assert.JSONEq(t, `{"YL":123.4}`, string(frame.Items))
+	})
+
+	t.Run("rain without status is rejected", func(t *testing.T) {
+		// 旧实现接受的3字节雨量,按规约缺少末尾4字节状态
+		_, err := ParseUploadData(DataTypeRain, []byte{0x00, 0x12, 0x34})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "设备状态")
+	})
+
+	t.Run("alarm carries status", func(t *testing.T) {
+		frame, err := ParseUploadData(DataTypeAlarm, []byte{0x00, 0x21, 0x80, 0x01})
+		require.NoError(t, err)
+		require.NotNil(t, frame.Status)
+		assert.Equal(t, uint16(0x0021), frame.Status.Alarm)
+		assert.Equal(t, uint16(0x8001), frame.Status.State)
+		assert.JSONEq(t, `{}`, string(frame.Items))
+	})
+
+	t.Run("alarm with time label", func(t *testing.T) {
+		collected := time.Date(2024, 11, 10, 8, 0, 0, 0, time.Local)
+		data := append([]byte{0x00, 0x21, 0x80, 0x01}, NewTimestamp(collected).Bytes()...)
+		frame, err := ParseUploadData(DataTypeAlarm, data)
+		require.NoError(t, err)
+		require.NotNil(t, frame.Status)
+		assert.Equal(t, uint16(0x0021), frame.Status.Alarm)
+		require.NotNil(t, frame.Timestamp)
+		assert.Equal(t, collected.Unix(), frame.Timestamp.Seconds())
+	})
+
+	t.Run("alarm too short for status", func(t *testing.T) {
+		_, err := ParseUploadData(DataTypeAlarm, []byte{0x00, 0x21})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseUploadData_Flow(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]float64
+	}{
+		{
+			name: "flow and total",
+			data: []byte{
+				0x56, 0x34, 0x12, 0x00, 0x00, // 123.456m³/s
+				0x00, 0x50, 0x34, 0x12, 0x00, // 12345000m³
+			},
+			want: map[string]float64{"LL": 123.456, "LLZ": 12345000},
+		},
+		{
+			name: "flow only",
+			data: []byte{0x56, 0x34, 0x12, 0x00, 0x00},
+			want: map[string]float64{"LL": 123.456},
+		},
+		{
+			name: "absent total",
+			data: []byte{0x00, 0x25, 0x00, 0x00, 0xF0, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA}, // -2.5m³/s
+			want: map[string]float64{"LL": -2.5},
+		},
+		{
+			name: "two meters, first without flow",
+			data: []byte{
+				0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0x00, 0x10, 0x00, 0x00, 0x00,
+				0x00, 0x10, 0x00, 0x00, 0x00, 0x99, 0x99, 0x99, 0x99, 0x07,
+			},
+			want: map[string]float64{"LLZ": 1000, "LL2": 1, "LLZ2": 799999999},
+		},
+		{
+			name: "flow in cubic metres per hour",
+			data: []byte{0x00, 0x00, 0x36, 0x00, 0x03}, // 360m³/h = 0.1m³/s
+			want: map[string]float64{"LL": 0.1},
+		},
+		{
+			name: "negative flow in cubic metres per hour",
+			data: []byte{0x00, 0x00, 0x36, 0x00, 0x33}, // -360m³/h
+			want: map[string]float64{"LL": -0.1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseItems(t, DataTypeFlow, tt.data))
+		})
+	}
+
+	_, err := ParseUploadData(DataTypeFlow, withStatus(0x56, 0x34, 0x12))
+	assert.Error(t, err)
+
+	_, err = ParseUploadData(DataTypeFlow, withStatus(0x56, 0x34, 0x12, 0x00, 0x01))
+	assert.Error(t, err, "unknown unit code")
+}
+
+func TestParseUploadData_PressureAndPower(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType byte
+		data     []byte
+		want     map[string]float64
+		wantErr  bool
+	}{
+		{
+			name:     "pressure",
+			dataType: DataTypePressure,
+			data:     []byte{0x50, 0x32, 0x01, 0x00}, // 132.50kPa
+			want:     map[string]float64{"SY": 132.5},
+		},
+		{
+			name:     "two pressure gauges",
+			dataType: DataTypePressure,
+			data:     []byte{0x50, 0x32, 0x01, 0x00, 0x99, 0x99, 0x99, 0x99},
+			want:     map[string]float64{"SY": 132.5, "SY2": 999999.99},
+		},
+		{
+			name:     "pressure bad length",
+			dataType: DataTypePressure,
+			data:     []byte{0x50, 0x32, 0x01},
+			wantErr:  true,
+		},
+		{
+			name:     "power",
+			dataType: DataTypePower,
+			data:     []byte{0x55, 0x12, 0x00}, // 1255kW
+			want:     map[string]float64{"GL": 1255},
+		},
+		{
+			name:     "power at full scale",
+			dataType: DataTypePower,
+			data:     []byte{0x99, 0x99, 0x99}, // 999999kW
+			want:     map[string]float64{"GL": 999999},
+		},
+		{
+			name:     "power bad length",
+			dataType: DataTypePower,
+			data:     []byte{0x55, 0x12, 0x00, 0x01},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr {
+				_, err := ParseUploadData(tt.dataType, withStatus(tt.data...))
+				assert.Error(t, err)
+				return
+			}
+			assert.Equal(t, tt.want, parseItems(t, tt.dataType, tt.data))
+		})
+	}
+}
+
+func TestParseUploadData_ElectricAndWeather(t *testing.T) {
+	t.Run("electric", func(t *testing.T) {
+		data := []byte{0x25, 0x67, 0x45, 0x23} // 234567.25kWh
+		assert.Equal(t, map[string]float64{"DL": 234567.25}, parseItems(t, DataTypeElectric, data))
+	})
+
+	t.Run("weather", func(t *testing.T) {
+		data := []byte{
+			0x13, 0x10, 0x00, // 气压1013×10²Pa
+			0x50, 0x23, 0x30, // 风速32.5m/s,风向3
+			0x52, 0xF0, // 气温-5.2℃
+		}
+		assert.Equal(t, map[string]float64{"QY": 1013, "FS": 32.5, "FX": 3, "QW": -5.2}, parseItems(t, DataTypeWeather, data))
+	})
+
+	t.Run("weather with absent elements", func(t *testing.T) {
+		data := []byte{
+			0xAA, 0xAA, 0x0A, // 不采集气压
+			0x05, 0x10, 0xA0, // 风速1.05m/s,风向无效
+			0x68, 0x02, // 气温26.8℃
+		}
+		assert.Equal(t, map[string]float64{"FS": 1.05, "QW": 26.8}, parseItems(t, DataTypeWeather, data))
+	})
+
+	t.Run("weather length mismatch", func(t *testing.T) {
+		_, err := ParseUploadData(DataTypeWeather, withStatus(0x13, 0x10, 0x00, 0x50, 0x23, 0x30, 0x52))
+		assert.Error(t, err)
+	})
+
+	t.Run("weather bad wind direction", func(t *testing.T) {
+		_, err := ParseUploadData(DataTypeWeather, withStatus(0x13, 0x10, 0x00, 0x50, 0x23, 0x90, 0x52, 0xF0))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseUploadData_SpeedAndGate(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType byte
+		data     []byte
+		want     map[string]float64
+	}{
+		{
+			name:     "speed",
+			dataType: DataTypeSpeed,
+			data:     []byte{0x50, 0x12, 0x00}, // 1.250m/s
+			want:     map[string]float64{"LS": 1.25},
+		},
+		{
+			name:     "negative speed",
+			dataType: DataTypeSpeed,
+			data:     []byte{0x99, 0x99, 0xF9}, // -99.999m/s
+			want:     map[string]float64{"LS": -99.999},
+		},
+		{
+			name:     "two speeds",
+			dataType: DataTypeSpeed,
+			data:     []byte{0x00, 0x25, 0x01, 0x50, 0x00, 0xF0}, // 12.5m/s, -0.05m/s
+			want:     map[string]float64{"LS": 12.5, "LS2": -0.05},
+		},
+		{
+			name:     "single gate",
+			dataType: DataTypeGate,
+			data:     []byte{0x35, 0x02, 0x00}, // 2.35m
+			want:     map[string]float64{"ZM": 2.35},
+		},
+		{
+			name:     "multiple gates",
+			dataType: DataTypeGate,
+			data:     []byte{0x35, 0x02, 0x00, 0x00, 0x00, 0x00, 0x20, 0x11, 0x00}, // 2.35m, 0m, 11.20m
+			want:     map[string]float64{"ZM": 2.35, "ZM2": 0, "ZM3": 11.2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseItems(t, tt.dataType, tt.data))
+		})
+	}
+
+	_, err := ParseUploadData(DataTypeGate, withStatus(0x35, 0x02))
+	assert.Error(t, err)
+}
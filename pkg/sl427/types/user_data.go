@@ -2,6 +2,7 @@
 package types
 
 import (
+	"encoding/binary"
 	"fmt"
 	"strings"
 )
@@ -13,12 +14,29 @@ type UserData struct {
 	AFN       AFN        // 功能码(1字节)
 	UserAFN   *byte      // 用户功能码(1字节,可选)
 	DataField []byte     // 数据域D的原始字节流
-	PW        *byte      // 密码PW(2字节,可选)
+	PW        *uint16    // 密码PW(2字节,可选)
 	Tp        *TimeLabel // 时间标签Tp(7字节,可选)
+	Extra     []byte     // 宽松模式下无法识别的尾部字节(位于时间标签之前)
 }
 
-// NewUserData 从字节流解析用户数据区
+// DecodeMode 用户数据区解码模式
+type DecodeMode int
+
+const (
+	// DecodeStrict 严格模式(默认): 按规约启发式拆分时间标签和密码
+	DecodeStrict DecodeMode = iota
+	// DecodeLenient 宽松模式: 只提取校验通过的时间标签,不猜测密码,
+	// 下行报文中可能是密码的尾部字节原样保留在Extra中
+	DecodeLenient
+)
+
+// NewUserData 从字节流解析用户数据区(严格模式)
 func NewUserData(data []byte) (*UserData, error) {
+	return NewUserDataWithMode(data, DecodeStrict)
+}
+
+// NewUserDataWithMode 按指定模式从字节流解析用户数据区
+func NewUserDataWithMode(data []byte, mode DecodeMode) (*UserData, error) {
 	if len(data) < 7 { // 最小长度:控制域(1)+地址域(5)+AFN(1)
 		return nil, fmt.Errorf("数据长度不足: %d", len(data))
 	}
@@ -78,8 +96,13 @@ func NewUserData(data []byte) (*UserData, error) {
 
 	// 6. 处理密码(如果存在)
 	if !ctrl.DIR() && len(restData) >= 2 { // 下行报文可能包含密码
-		pw := restData[len(restData)-2]
-		userData.PW = &pw
+		if mode == DecodeLenient {
+			// 无法确认是否为密码,保留原始字节
+			userData.Extra = restData[len(restData)-2:]
+		} else {
+			pw := binary.BigEndian.Uint16(restData[len(restData)-2:])
+			userData.PW = &pw
+		}
 		restData = restData[:len(restData)-2]
 	}
 
@@ -89,6 +112,11 @@ func NewUserData(data []byte) (*UserData, error) {
 	return userData, nil
 }
 
+// IsTimeLabel 判断数据是否形如有效的时间标签,解码时据此识别用户数据区末尾的Tp
+func IsTimeLabel(data []byte) bool {
+	return isValidTimeLabel(data)
+}
+
 // isValidTimeLabel 简单验证是否为有效的时间标签
 func isValidTimeLabel(data []byte) bool {
 	if len(data) != 7 {
@@ -120,22 +148,13 @@ func isValidTimeLabel(data []byte) bool {
 }
 
 // Bytes 将用户数据区编码为字节流
+// 解析时识别出的各字段(含Extra)均按原顺序原样写回,
+// 因此对任意能被NewUserData/NewUserDataWithMode解析的data, Bytes()与data逐字节相等。
+// 附加信息域的识别基于启发式: 数据域末尾恰好形如时间标签时会被识别为Tp,
+// 此时字节流仍一致,但DataField/Tp的拆分可能与构建时不同。
 func (u *UserData) Bytes() []byte {
-	// 计算总长度
-	length := u.Control.Length() + 5 + 1 // 控制域 + 地址域 + AFN
-	if u.UserAFN != nil {
-		length++
-	}
-	if u.PW != nil {
-		length += 2
-	}
-	length += len(u.DataField)
-	if u.Tp != nil {
-		length += 7
-	}
-
 	// 分配缓冲区
-	buf := make([]byte, 0, length)
+	buf := make([]byte, 0, u.EncodedLen())
 
 	// 1. 写入控制域
 	buf = append(buf, u.Control.Bytes()...)
@@ -156,10 +175,13 @@ func (u *UserData) Bytes() []byte {
 
 	// 6. 写入密码(如果存在)
 	if u.PW != nil {
-		buf = append(buf, *u.PW)
+		buf = binary.BigEndian.AppendUint16(buf, *u.PW)
 	}
 
-	// 7. 写入时间标签(如果存在)
+	// 7. 写入未识别的尾部字节(如果存在)
+	buf = append(buf, u.Extra...)
+
+	// 8. 写入时间标签(如果存在)
 	if u.Tp != nil {
 		buf = append(buf, u.Tp.Bytes()...)
 	}
@@ -167,28 +189,68 @@ func (u *UserData) Bytes() []byte {
 	return buf
 }
 
+// EncodedLen 返回用户数据区编码后的字节数(即帧长度L),不实际编码
+func (u *UserData) EncodedLen() int {
+	length := u.Control.Length() + AddressLen + 1 // 控制域 + 地址域 + AFN
+	if u.UserAFN != nil {
+		length++
+	}
+	length += len(u.DataField)
+	if u.PW != nil {
+		length += 2
+	}
+	length += len(u.Extra)
+	if u.Tp != nil {
+		length += TimeLabelLen
+	}
+	return length
+}
+
 // Validate 验证用户数据区的有效性
+// 执行与编码相同的检查,校验通过的用户数据区可以由Bytes()编码并被NewUserData还原,
+// 可在发送前以较小的代价确认数据有效
 func (u *UserData) Validate() error {
-	// 1. 验证地址
+	// 1. 验证控制域
+	if u.Control.IsDIV() && u.Control.Length() != 2 {
+		return fmt.Errorf("拆分帧缺少拆分帧计数")
+	}
+
+	// 2. 验证地址
+	if u.Address == nil {
+		return fmt.Errorf("缺少地址域")
+	}
 	if err := u.Address.Validate(); err != nil {
 		return fmt.Errorf("无效的地址域: %v", err)
 	}
 
-	// 2. 验证功能码
+	// 3. 验证功能码
 	if !u.AFN.IsValid() {
 		return fmt.Errorf("无效的功能码: %02X", u.AFN)
 	}
 
-	// 3. 验证用户功能码
+	// 4. 验证用户功能码
 	if u.AFN == 0xFF && u.UserAFN == nil {
 		return fmt.Errorf("缺少用户功能码")
 	}
+	if u.AFN != 0xFF && u.UserAFN != nil {
+		return fmt.Errorf("功能码%02X不应携带用户功能码", byte(u.AFN))
+	}
 
-	// 4. 验证密码(下行报文)
+	// 5. 验证密码(下行报文)
 	if !u.Control.DIR() && u.PW == nil {
 		return fmt.Errorf("下行报文缺少密码")
 	}
 
+	// 6. 验证时间标签
+	if u.Tp != nil && !isValidTimeLabel(u.Tp.Bytes()) {
+		return fmt.Errorf("无效的时间标签: % X", u.Tp.Bytes())
+	}
+
+	// 7. 验证长度
+	if n := u.EncodedLen(); n > MaxFrameLen {
+		return fmt.Errorf("用户数据区过长: %d(最大%d)", n, MaxFrameLen)
+	}
+
 	return nil
 }
 
@@ -203,7 +265,10 @@ func (u *UserData) String() string {
 	}
 	sb.WriteString(fmt.Sprintf("DataField: %X\n", u.DataField))
 	if u.PW != nil {
-		sb.WriteString(fmt.Sprintf("PW: %02X\n", *u.PW))
+		sb.WriteString(fmt.Sprintf("PW: %04X\n", *u.PW))
+	}
+	if len(u.Extra) > 0 {
+		sb.WriteString(fmt.Sprintf("Extra: %X\n", u.Extra))
 	}
 	sb.WriteString(fmt.Sprintf("TimeLabel: %+v", u.Tp))
 	return sb.String()
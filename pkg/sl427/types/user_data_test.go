@@ -0,0 +1,149 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 下行报文: 控制域 + 地址域 + AFN + 数据域(1字节) + 2字节尾部 + 时间标签
+func downlinkUserData() []byte {
+	data := []byte{
+		0x00,                         // 控制域(下行)
+		0x12, 0x34, 0x56, 0x00, 0x01, // 地址域
+		byte(AFNUpload), // 功能码
+		ModeUpload,      // 数据域
+		0xAB, 0xCD,      // 可能是密码,也可能是厂商扩展
+	}
+	tp := NewTimestamp(time.Date(2024, 11, 10, 17, 30, 30, 0, time.Local))
+	return append(data, tp.Bytes()...)
+}
+
+func TestNewUserDataWithMode_Lenient(t *testing.T) {
+	data := downlinkUserData()
+
+	ud, err := NewUserDataWithMode(data, DecodeLenient)
+	require.NoError(t, err)
+	assert.Nil(t, ud.PW)
+	assert.Equal(t, []byte{0xAB, 0xCD}, ud.Extra)
+	assert.Equal(t, []byte{ModeUpload}, ud.DataField)
+	require.NotNil(t, ud.Tp)
+	assert.Equal(t, data, ud.Bytes())
+}
+
+func TestNewUserDataWithMode_Strict(t *testing.T) {
+	data := downlinkUserData()
+
+	ud, err := NewUserData(data)
+	require.NoError(t, err)
+	require.NotNil(t, ud.PW)
+	assert.Equal(t, uint16(0xABCD), *ud.PW)
+	assert.Empty(t, ud.Extra)
+	assert.Equal(t, data, ud.Bytes())
+}
+
+func TestUserData_PasswordTwoBytes(t *testing.T) {
+	addr, err := NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	pw := uint16(0xABCD)
+	ud := UserData{Control: *NewControl(0), Address: addr, AFN: AFNUpload, DataField: []byte{ModeUpload}, PW: &pw}
+
+	// 规约中密码PW为2字节,高字节在前
+	raw := ud.Bytes()
+	assert.Equal(t, []byte{ModeUpload, 0xAB, 0xCD}, raw[len(raw)-3:])
+	assert.Equal(t, len(raw), ud.EncodedLen())
+}
+
+func FuzzUserDataRoundTrip(f *testing.F) {
+	f.Add(downlinkUserData())
+	f.Add([]byte{0x82, 0x12, 0x34, 0x56, 0x00, 0x01, 0xC0, 0x34, 0x12, 0x05, 0x00})
+	f.Add([]byte{0xC2, 0x03, 0x00, 0x80, 0x00, 0x00, 0x01, 0xC0, 0x01})
+	f.Add([]byte{0x80, 0x12, 0x34, 0x56, 0x00, 0x01, 0xFF, 0x10, 0x01, 0x02})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, mode := range []DecodeMode{DecodeStrict, DecodeLenient} {
+			ud, err := NewUserDataWithMode(data, mode)
+			if err != nil {
+				continue
+			}
+			if got := ud.Bytes(); !bytes.Equal(got, data) {
+				t.Fatalf("mode %d round trip mismatch:\nwant % X\ngot  % X", mode, data, got)
+			}
+		}
+	})
+}
+
+func TestUserData_EncodedLen(t *testing.T) {
+	addr, err := NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	div := NewControl(DirBit)
+	div.SetDIV(2)
+	userAFN := byte(0x10)
+	pw := uint16(0x1234)
+
+	tests := []struct {
+		name string
+		ud   UserData
+	}{
+		{"minimal", UserData{Control: *NewControl(DirBit), Address: addr, AFN: AFNUpload}},
+		{"with data", UserData{Control: *NewControl(DirBit), Address: addr, AFN: AFNUpload, DataField: []byte{1, 2, 3}}},
+		{"split control", UserData{Control: *div, Address: addr, AFN: AFNUpload, DataField: []byte{1}}},
+		{"user AFN", UserData{Control: *NewControl(DirBit), Address: addr, AFN: 0xFF, UserAFN: &userAFN}},
+		{"full downlink", UserData{
+			Control: *NewControl(0), Address: addr, AFN: AFNUpload, DataField: []byte{1},
+			PW: &pw, Extra: []byte{0xAA}, Tp: NewTimestamp(time.Now()),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := tt.ud.Bytes()
+			assert.Equal(t, len(raw), tt.ud.EncodedLen())
+
+			frame := Frame{UserDataRaw: raw}
+			assert.Equal(t, len(raw)+5, frame.EncodedLen())
+			assert.Equal(t, frame.EncodedLen(), len(frame.Raw()))
+		})
+	}
+}
+
+func TestUserData_Validate(t *testing.T) {
+	addr, err := NewAddressV1([]byte{0x12, 0x34, 0x56}, 1)
+	require.NoError(t, err)
+	div := NewControl(DirBit | DivBit)
+	userAFN := byte(0x10)
+	pw := uint16(0x1234)
+
+	tests := []struct {
+		name    string
+		ud      UserData
+		wantErr bool
+	}{
+		{"valid upload", UserData{Control: *NewControl(DirBit), Address: addr, AFN: AFNUpload, DataField: []byte{1}}, false},
+		{"valid downlink", UserData{Control: *NewControl(0), Address: addr, AFN: AFNUpload, PW: &pw}, false},
+		{"missing address", UserData{Control: *NewControl(DirBit), AFN: AFNUpload}, true},
+		{"DIV without count", UserData{Control: *div, Address: addr, AFN: AFNUpload}, true},
+		{"user AFN on standard AFN", UserData{Control: *NewControl(DirBit), Address: addr, AFN: AFNUpload, UserAFN: &userAFN}, true},
+		{"invalid time label", UserData{Control: *NewControl(DirBit), Address: addr, AFN: AFNUpload, Tp: &TimeLabel{Hour: 0x25, Day: 1, Month: 1}}, true},
+		{"too long", UserData{Control: *NewControl(DirBit), Address: addr, AFN: AFNUpload, DataField: make([]byte, MaxFrameLen)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ud.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// 校验通过即可编码并还原
+			got, err := NewUserData(tt.ud.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, tt.ud.Bytes(), got.Bytes())
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// pkg/sl427/types/validator.go
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427"
+)
+
+// ValueValidator 数据项校验函数
+// key为数据项的json key(如PH、SW2),value为解析后的实际值
+type ValueValidator func(key string, value float64) error
+
+// validatorRegistry 按类型码注册的数据项校验函数
+var validatorRegistry = struct {
+	sync.RWMutex
+	m map[byte][]ValueValidator
+}{m: make(map[byte][]ValueValidator)}
+
+// RegisterValidator 为类型码注册数据项校验函数,同一类型码可注册多个
+// ParseUploadData解析出数据项后依次调用,用于尽早发现超出物理范围的传感器故障值,
+// 如pH值大于14、雨量为负等
+func RegisterValidator(dataType byte, fn ValueValidator) {
+	validatorRegistry.Lock()
+	defer validatorRegistry.Unlock()
+	validatorRegistry.m[dataType] = append(validatorRegistry.m[dataType], fn)
+}
+
+// ClearValidators 清除类型码已注册的全部校验函数
+func ClearValidators(dataType byte) {
+	validatorRegistry.Lock()
+	defer validatorRegistry.Unlock()
+	delete(validatorRegistry.m, dataType)
+}
+
+// RangeValidator 返回只校验指定key取值范围[min,max]的校验函数
+func RangeValidator(key string, min, max float64) ValueValidator {
+	return func(k string, value float64) error {
+		if k != key {
+			return nil
+		}
+		if value < min || value > max {
+			return fmt.Errorf("%v超出范围[%v,%v]", value, min, max)
+		}
+		return nil
+	}
+}
+
+// validateItems 使用类型码注册的校验函数校验数据项
+// 校验失败返回错误码为ErrCodeInvalidValue的错误
+func validateItems(dataType byte, items json.RawMessage) error {
+	validatorRegistry.RLock()
+	validators := validatorRegistry.m[dataType]
+	validatorRegistry.RUnlock()
+	if len(validators) == 0 {
+		return nil
+	}
+
+	var values map[string]float64
+	if err := json.Unmarshal(items, &values); err != nil {
+		return fmt.Errorf("校验数据项失败: %v", err)
+	}
+
+	// 按key排序,保证多个数据项不合法时返回的错误稳定
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, fn := range validators {
+			if err := fn(k, values[k]); err != nil {
+				return sl427.WrapError(sl427.ErrCodeInvalidValue,
+					fmt.Sprintf("类型码%d数据项%s校验失败", dataType, k), err)
+			}
+		}
+	}
+	return nil
+}
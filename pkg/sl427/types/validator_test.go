@@ -0,0 +1,33 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/ThingsPanel/go-sl427/pkg/sl427"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterValidator(t *testing.T) {
+	RegisterValidator(DataTypeQuality, RangeValidator("PH", 0, 14))
+	defer ClearValidators(DataTypeQuality)
+
+	quality := func(lo, hi byte) []byte {
+		// 位图只置pH位(bit1),实测值4字节BCD低位在前,2位小数,末尾4字节设备状态
+		return []byte{0x02, 0x00, 0x00, 0x00, 0x00, lo, hi, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	}
+
+	frame, err := ParseUploadData(DataTypeQuality, quality(0x25, 0x07))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"PH":7.25}`, string(frame.Items))
+
+	// pH 15.25 超出范围
+	_, err = ParseUploadData(DataTypeQuality, quality(0x25, 0x15))
+	require.Error(t, err)
+	assert.True(t, sl427.IsErrorCode(err, sl427.ErrCodeInvalidValue))
+	assert.Contains(t, err.Error(), "PH")
+
+	// 其他类型码不受影响
+	_, err = ParseUploadData(DataTypeRain, []byte{0x00, 0x12, 0x34, 0x00, 0x00, 0x00, 0x00})
+	assert.NoError(t, err)
+}